@@ -0,0 +1,237 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Agent is a named bundle of a system prompt and the subset of the
+// Toolbox it exposes to the model, selectable at startup via --agent.
+type Agent struct {
+	Name         string   `yaml:"name"`
+	SystemPrompt string   `yaml:"system_prompt"`
+	Tools        []string `yaml:"tools"`
+}
+
+// readonlyAllowedCommands is the safe allowlist the "readonly" agent
+// hard-restricts bash to, independent of the normal allow-once/always
+// permission prompt.
+var readonlyAllowedCommands = map[string]bool{
+	"ls": true, "cat": true, "grep": true, "find": true,
+	"head": true, "tail": true, "wc": true, "git": true, "pwd": true,
+}
+
+// findMutatingFlags are find(1) primaries that execute another program
+// or touch the filesystem rather than just printing matches -- e.g.
+// "find . -exec rm -rf {} +", which contains no shellMetacharacter and
+// so would otherwise sail through the readonly agent's hard allowlist.
+var findMutatingFlags = map[string]bool{
+	"-exec": true, "-execdir": true, "-ok": true, "-okdir": true,
+	"-delete": true, "-fprint": true, "-fprint0": true, "-fprintf": true,
+}
+
+// gitReadonlySubcommands are the only git subcommands the "readonly"
+// agent may run; anything else (e.g. "clean", "checkout", "apply",
+// "stash") can modify the working tree or history.
+var gitReadonlySubcommands = map[string]bool{
+	"status": true, "log": true, "diff": true, "show": true, "blame": true,
+}
+
+// builtinAgents ships without any config file: shell exposes bash plus
+// the general-purpose automation tools, readonly restricts bash to
+// readonlyAllowedCommands, and coder adds the file-editing and Go
+// tooling on top of shell's.
+var builtinAgents = map[string]Agent{
+	"shell": {
+		Name:         "shell",
+		SystemPrompt: "You are a helpful assistant that MUST use tools to complete tasks. You have access to 'bash' for executing commands, 'expect' for scripting interactive programs (REPLs, ssh, sudo prompts), 'shell_batch' for running many commands concurrently, and 'finish' when the task is complete. You MUST call one of these tools in every response - never respond without using a tool.",
+		Tools:        []string{"bash", "expect", "shell_batch", "finish"},
+	},
+	"readonly": {
+		Name:         "readonly",
+		SystemPrompt: "You are a read-only assistant restricted to a safe allowlist of inspection commands (ls, cat, grep, find, head, tail, wc, git, pwd). You must never attempt to modify files or state. Call 'finish' once you have an answer.",
+		Tools:        []string{"bash", "finish"},
+	},
+	"coder": {
+		Name:         "coder",
+		SystemPrompt: "You are a coding assistant. Prefer 'read_file', 'write_file' and 'modify_file' over shelling out to sed or heredocs for editing source files. For Go code, prefer 'gofmt', 'goimports' and 'gorewrite'/'comby' over shelling out to those binaries directly. 'sed' and 'todo' are available for non-Go text edits and checklist files; 'ops_list'/'ops_revert' inspect and undo their past mutations. Use 'bash' for everything else, and call 'finish' once the task is complete.",
+		Tools:        []string{"bash", "read_file", "write_file", "modify_file", "sed", "comby", "gorewrite", "gofmt", "goimports", "todo", "ops_list", "ops_revert", "finish"},
+	},
+}
+
+// allowlistCommands renders a commandAllowlist as a sorted, comma
+// separated list for use in denial messages.
+func allowlistCommands(allowlist map[string]bool) string {
+	names := make([]string, 0, len(allowlist))
+	for name := range allowlist {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// shellMetacharacters are the characters that let a string passed to
+// `bash -c` do more than invoke one simple command with arguments:
+// chaining (; & && ||), piping (|), substitution ($( ` <( ), and
+// redirection (< >). Checking only a command's first word -- as the
+// normal "always allow" cache does -- is trivially defeated by e.g.
+// "ls; rm -rf /tmp", which passes a first-word check against "ls" and
+// then runs the rest under the same shell.
+const shellMetacharacters = ";&|`$()<>\n"
+
+// splitShellWords performs the same word-splitting and quote removal
+// bash would do before find/git ever see argv, so validateAllowlistedCommand
+// compares against the tokens the program actually receives rather than
+// raw substrings of the unprocessed command string. Without this, a
+// quoting trick like "find . -exec'''' echo PWNED {} +" slips past a
+// literal-string check on the word "-exec''''" (which never appears in
+// findMutatingFlags) while bash strips the empty quotes and find
+// receives a plain "-exec". This only needs to handle the subset of
+// POSIX quoting relevant to argv splitting -- single quotes, double
+// quotes with backslash escapes, and a bare backslash escape outside
+// quotes -- since command substitution, variable expansion and the
+// other shellMetacharacters are already rejected before this runs.
+func splitShellWords(command string) ([]string, error) {
+	var words []string
+	var cur strings.Builder
+	haveWord := false
+	inSingle, inDouble := false, false
+
+	for i := 0; i < len(command); i++ {
+		c := command[i]
+		switch {
+		case inSingle:
+			if c == '\'' {
+				inSingle = false
+			} else {
+				cur.WriteByte(c)
+			}
+		case inDouble:
+			switch {
+			case c == '"':
+				inDouble = false
+			case c == '\\' && i+1 < len(command) && (command[i+1] == '"' || command[i+1] == '\\'):
+				cur.WriteByte(command[i+1])
+				i++
+			default:
+				cur.WriteByte(c)
+			}
+		case c == '\'':
+			inSingle = true
+			haveWord = true
+		case c == '"':
+			inDouble = true
+			haveWord = true
+		case c == '\\' && i+1 < len(command):
+			cur.WriteByte(command[i+1])
+			i++
+			haveWord = true
+		case c == ' ' || c == '\t' || c == '\n':
+			if haveWord {
+				words = append(words, cur.String())
+				cur.Reset()
+				haveWord = false
+			}
+		default:
+			cur.WriteByte(c)
+			haveWord = true
+		}
+	}
+	if inSingle || inDouble {
+		return nil, fmt.Errorf("unterminated quote in command: %q", command)
+	}
+	if haveWord {
+		words = append(words, cur.String())
+	}
+	return words, nil
+}
+
+// validateAllowlistedCommand enforces a hard command allowlist (e.g.
+// the "readonly" agent's): command must contain no shell
+// metacharacters, and its first word must be in allowlist. This is
+// the check commandAllowlist-restricted agents apply in place of (not
+// in addition to) the normal first-word-only permission prompt, since
+// that check alone doesn't stop a chained or substituted command.
+// find and git get additional, command-specific restrictions beyond
+// the first word: neither is safe to allow unconditionally even once
+// metacharacters are ruled out, since both have subcommands/primaries
+// that execute arbitrary programs or mutate the working tree. Those
+// checks compare against splitShellWords' tokens, not raw
+// whitespace-separated substrings, so quote removal can't be used to
+// disguise a blocked flag (see splitShellWords).
+func validateAllowlistedCommand(command string, allowlist map[string]bool) error {
+	if strings.ContainsAny(command, shellMetacharacters) {
+		return fmt.Errorf("command contains shell metacharacters not permitted for this agent: %q", command)
+	}
+
+	fields, err := splitShellWords(command)
+	if err != nil {
+		return err
+	}
+	if len(fields) == 0 {
+		return fmt.Errorf("empty command")
+	}
+	if !allowlist[fields[0]] {
+		return fmt.Errorf("'%s' is not in this agent's allowed commands: %s", fields[0], allowlistCommands(allowlist))
+	}
+
+	switch fields[0] {
+	case "find":
+		for _, arg := range fields[1:] {
+			if findMutatingFlags[arg] {
+				return fmt.Errorf("'find %s' is not permitted for this agent: find primaries that execute or write are disallowed", arg)
+			}
+		}
+	case "git":
+		if len(fields) < 2 || !gitReadonlySubcommands[fields[1]] {
+			sub := ""
+			if len(fields) >= 2 {
+				sub = fields[1]
+			}
+			return fmt.Errorf("'git %s' is not in this agent's allowed git subcommands: %s", sub, allowlistCommands(gitReadonlySubcommands))
+		}
+	}
+
+	return nil
+}
+
+// loadAgents reads ~/.config/agent/agents.yaml if present, merging its
+// entries on top of builtinAgents. A user config entry with the same
+// name as a builtin overrides it.
+func loadAgents() (map[string]Agent, error) {
+	agents := make(map[string]Agent, len(builtinAgents))
+	for name, a := range builtinAgents {
+		agents[name] = a
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return agents, nil
+	}
+
+	data, err := os.ReadFile(filepath.Join(home, ".config", "agent", "agents.yaml"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return agents, nil
+		}
+		return agents, err
+	}
+
+	var config struct {
+		Agents []Agent `yaml:"agents"`
+	}
+	if err := yaml.Unmarshal(data, &config); err != nil {
+		return agents, fmt.Errorf("parsing agents.yaml: %w", err)
+	}
+
+	for _, a := range config.Agents {
+		agents[a.Name] = a
+	}
+
+	return agents, nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import "testing"
+
+func TestValidateAllowlistedCommand(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		wantErr bool
+	}{
+		{name: "plain allowed command", command: "ls -la", wantErr: false},
+		{name: "not in allowlist", command: "rm -rf /", wantErr: true},
+		{name: "chained command via semicolon", command: "ls; rm -rf /tmp", wantErr: true},
+		{name: "substitution", command: "cat $(rm -rf /tmp)", wantErr: true},
+		{name: "piped command", command: "cat foo | sh", wantErr: true},
+		{name: "redirection", command: "cat foo > /etc/passwd", wantErr: true},
+		{name: "find without exec is fine", command: "find . -name '*.go'", wantErr: false},
+		{name: "find -exec is blocked even without a terminating semicolon", command: "find . -exec rm -rf {} +", wantErr: true},
+		{name: "find -delete is blocked", command: "find . -name '*.go' -delete", wantErr: true},
+		{name: "find -execdir is blocked", command: "find . -execdir touch {} +", wantErr: true},
+		{name: "git status is allowed", command: "git status", wantErr: false},
+		{name: "git log is allowed", command: "git log -n 5", wantErr: false},
+		{name: "bare git is blocked", command: "git", wantErr: true},
+		{name: "git clean is blocked", command: "git clean -fd", wantErr: true},
+		{name: "git checkout is blocked", command: "git checkout -- .", wantErr: true},
+		{name: "git apply is blocked", command: "git apply patch.diff", wantErr: true},
+		{name: "find -exec disguised with empty quotes is blocked", command: "find . -exec'''' echo PWNED {} +", wantErr: true},
+		{name: "find -delete disguised with empty quotes is blocked", command: `find . -name "*.go" -del""ete`, wantErr: true},
+		{name: "find -execdir disguised with empty quotes is blocked", command: "find . -exec''dir touch {} +", wantErr: true},
+		{name: "find -exec disguised with a quoted letter is blocked", command: `find . -e''x'e'c echo PWNED {} +`, wantErr: true},
+		{name: "unterminated quote is rejected", command: "find . -name 'unterminated", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateAllowlistedCommand(tt.command, readonlyAllowedCommands)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateAllowlistedCommand(%q) error = %v, wantErr %v", tt.command, err, tt.wantErr)
+			}
+		})
+	}
+}
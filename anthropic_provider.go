@@ -0,0 +1,270 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// anthropicProvider talks to Anthropic's native /v1/messages API
+// directly, since its tool-use schema (content blocks rather than a
+// separate tool_calls array) doesn't fit the OpenAI client.
+type anthropicProvider struct {
+	apiKey  string
+	baseURL string
+	model   string
+	http    *http.Client
+}
+
+func newAnthropicProvider(apiKey, baseURL, model string) *anthropicProvider {
+	return &anthropicProvider{apiKey: apiKey, baseURL: baseURL, model: model, http: &http.Client{}}
+}
+
+type anthropicTool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	InputSchema map[string]interface{} `json:"input_schema"`
+}
+
+type anthropicContentBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text,omitempty"`
+	ID        string          `json:"id,omitempty"`
+	Name      string          `json:"name,omitempty"`
+	Input     json.RawMessage `json:"input,omitempty"`
+	ToolUseID string          `json:"tool_use_id,omitempty"`
+	Content   string          `json:"content,omitempty"`
+}
+
+type anthropicMessage struct {
+	Role    string                  `json:"role"`
+	Content []anthropicContentBlock `json:"content"`
+}
+
+type anthropicRequest struct {
+	Model     string             `json:"model"`
+	System    string             `json:"system,omitempty"`
+	Messages  []anthropicMessage `json:"messages"`
+	Tools     []anthropicTool    `json:"tools,omitempty"`
+	MaxTokens int                `json:"max_tokens"`
+	Stream    bool               `json:"stream,omitempty"`
+}
+
+type anthropicUsage struct {
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+}
+
+type anthropicResponse struct {
+	Role    string                  `json:"role"`
+	Content []anthropicContentBlock `json:"content"`
+	Usage   anthropicUsage          `json:"usage"`
+}
+
+// buildRequest translates the provider-agnostic message list into
+// Anthropic's shape: system-role messages are folded into the
+// top-level System field (Anthropic has no "system" message role),
+// assistant tool calls become "tool_use" blocks, and tool-result
+// messages become a user message carrying a "tool_result" block.
+func (p *anthropicProvider) buildRequest(msgs []Message, tools []ToolSpec, stream bool) anthropicRequest {
+	req := anthropicRequest{Model: p.model, MaxTokens: 4096, Stream: stream}
+
+	for _, m := range msgs {
+		switch m.Role {
+		case "system":
+			if req.System == "" {
+				req.System = m.Content
+			} else {
+				req.System += "\n" + m.Content
+			}
+		case "user":
+			req.Messages = append(req.Messages, anthropicMessage{
+				Role:    "user",
+				Content: []anthropicContentBlock{{Type: "text", Text: m.Content}},
+			})
+		case "assistant":
+			var blocks []anthropicContentBlock
+			if m.Content != "" {
+				blocks = append(blocks, anthropicContentBlock{Type: "text", Text: m.Content})
+			}
+			for _, tc := range m.ToolCalls {
+				blocks = append(blocks, anthropicContentBlock{
+					Type:  "tool_use",
+					ID:    tc.ID,
+					Name:  tc.Name,
+					Input: json.RawMessage(tc.Arguments),
+				})
+			}
+			req.Messages = append(req.Messages, anthropicMessage{Role: "assistant", Content: blocks})
+		case "tool":
+			req.Messages = append(req.Messages, anthropicMessage{
+				Role: "user",
+				Content: []anthropicContentBlock{{
+					Type:      "tool_result",
+					ToolUseID: m.ToolCallID,
+					Content:   m.Content,
+				}},
+			})
+		}
+	}
+
+	for _, t := range tools {
+		req.Tools = append(req.Tools, anthropicTool{Name: t.Name, Description: t.Description, InputSchema: t.Parameters})
+	}
+
+	return req
+}
+
+func (p *anthropicProvider) doRequest(ctx context.Context, body anthropicRequest) (*http.Response, error) {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", p.baseURL+"/v1/messages", bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("x-api-key", p.apiKey)
+	httpReq.Header.Set("anthropic-version", "2023-06-01")
+
+	resp, err := p.http.Do(httpReq)
+	if err != nil {
+		return nil, &NetworkError{Err: err}
+	}
+	if resp.StatusCode >= 400 {
+		defer resp.Body.Close()
+		payload, _ := io.ReadAll(resp.Body)
+		return nil, classifyHTTPError(resp.StatusCode, string(payload), resp.Header)
+	}
+	return resp, nil
+}
+
+func (p *anthropicProvider) Chat(ctx context.Context, msgs []Message, tools []ToolSpec) (Response, error) {
+	resp, err := p.doRequest(ctx, p.buildRequest(msgs, tools, false))
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var parsed anthropicResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return Response{}, err
+	}
+
+	return Response{
+		Message: fromAnthropicContent(parsed.Content),
+		Usage:   Usage{PromptTokens: parsed.Usage.InputTokens, CompletionTokens: parsed.Usage.OutputTokens},
+	}, nil
+}
+
+func fromAnthropicContent(blocks []anthropicContentBlock) Message {
+	m := Message{Role: "assistant"}
+	var text strings.Builder
+	for _, b := range blocks {
+		switch b.Type {
+		case "text":
+			text.WriteString(b.Text)
+		case "tool_use":
+			m.ToolCalls = append(m.ToolCalls, ToolCall{ID: b.ID, Name: b.Name, Arguments: string(b.Input)})
+		}
+	}
+	m.Content = text.String()
+	return m
+}
+
+// anthropicPendingToolUse accumulates one streamed "tool_use" content
+// block's partial-JSON input deltas until its content_block_stop.
+type anthropicPendingToolUse struct {
+	id, name string
+	input    strings.Builder
+}
+
+func (p *anthropicProvider) ChatStream(ctx context.Context, msgs []Message, tools []ToolSpec, onDelta func(StreamDelta)) (Response, error) {
+	resp, err := p.doRequest(ctx, p.buildRequest(msgs, tools, true))
+	if err != nil {
+		return Response{}, err
+	}
+	defer resp.Body.Close()
+
+	var text strings.Builder
+	var blocks []*anthropicPendingToolUse
+	var current *anthropicPendingToolUse
+	var usage anthropicUsage
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "data: ") {
+			continue
+		}
+		payload := strings.TrimPrefix(line, "data: ")
+
+		var event struct {
+			Type    string `json:"type"`
+			Message struct {
+				Usage anthropicUsage `json:"usage"`
+			} `json:"message"`
+			Usage        anthropicUsage `json:"usage"`
+			ContentBlock struct {
+				Type string `json:"type"`
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"content_block"`
+			Delta struct {
+				Type        string `json:"type"`
+				Text        string `json:"text"`
+				PartialJSON string `json:"partial_json"`
+			} `json:"delta"`
+		}
+		if err := json.Unmarshal([]byte(payload), &event); err != nil {
+			continue
+		}
+
+		switch event.Type {
+		case "message_start":
+			usage.InputTokens = event.Message.Usage.InputTokens
+		case "message_delta":
+			if event.Usage.OutputTokens != 0 {
+				usage.OutputTokens = event.Usage.OutputTokens
+			}
+		case "content_block_start":
+			if event.ContentBlock.Type == "tool_use" {
+				current = &anthropicPendingToolUse{id: event.ContentBlock.ID, name: event.ContentBlock.Name}
+				blocks = append(blocks, current)
+			} else {
+				current = nil
+			}
+		case "content_block_delta":
+			switch event.Delta.Type {
+			case "text_delta":
+				text.WriteString(event.Delta.Text)
+				onDelta(StreamDelta(event.Delta.Text))
+			case "input_json_delta":
+				if current != nil {
+					current.input.WriteString(event.Delta.PartialJSON)
+				}
+			}
+		case "content_block_stop":
+			current = nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return Response{}, err
+	}
+
+	message := Message{Role: "assistant", Content: text.String()}
+	for _, b := range blocks {
+		message.ToolCalls = append(message.ToolCalls, ToolCall{ID: b.id, Name: b.name, Arguments: b.input.String()})
+	}
+	return Response{
+		Message: message,
+		Usage:   Usage{PromptTokens: usage.InputTokens, CompletionTokens: usage.OutputTokens},
+	}, nil
+}
@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os/exec"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// BatchCommand is a single command to run as part of executeShellBatch.
+// Label is used both for output line-prefixing and, when sharding, as
+// the hash key that decides which shard the command belongs to.
+type BatchCommand struct {
+	Label   string
+	Command string
+	Timeout time.Duration
+}
+
+// BatchOpts configures executeShellBatch.
+type BatchOpts struct {
+	MaxParallel   int
+	Shard         int
+	Shards        int
+	GlobalTimeout time.Duration
+	FailFast      bool
+}
+
+// ShellBatchArgs are the arguments for the shell_batch tool.
+type ShellBatchArgs struct {
+	Commands    []BatchCommandArgs `json:"commands"`
+	MaxParallel int                `json:"max_parallel"`
+	Shard       int                `json:"shard"`
+	Shards      int                `json:"shards"`
+	FailFast    bool               `json:"fail_fast"`
+}
+
+// BatchCommandArgs is the JSON shape of one BatchCommand in
+// ShellBatchArgs.Commands.
+type BatchCommandArgs struct {
+	Label   string `json:"label"`
+	Command string `json:"command"`
+}
+
+// BatchResult is the outcome of a single command within a batch.
+type BatchResult struct {
+	Index    int
+	Label    string
+	ExitCode int
+	Stdout   string
+	Stderr   string
+	Duration time.Duration
+	TimedOut bool
+}
+
+// BatchSummary aggregates the outcomes of a full executeShellBatch run.
+type BatchSummary struct {
+	Passed   int
+	Failed   int
+	TimedOut int
+	Total    time.Duration
+}
+
+// BatchRun is the handle returned by executeShellBatch: Results streams
+// each command's outcome as it completes, and Summary blocks until the
+// run has finished (i.e. Results has been drained) and returns the
+// aggregate counts.
+type BatchRun struct {
+	Results <-chan BatchResult
+
+	done    chan struct{}
+	summary BatchSummary
+}
+
+func (r *BatchRun) Summary() BatchSummary {
+	<-r.done
+	return r.summary
+}
+
+// executeShellBatch runs commands concurrently with a worker pool bounded
+// by opts.MaxParallel (default runtime.NumCPU()), optionally restricted
+// to one shard of opts.Shards (mirroring Go's test runner -shard/-shards
+// flags, hashing each command's Label with FNV-1a). When opts.FailFast
+// is set, no new commands are started once one has failed or timed out.
+func executeShellBatch(commands []BatchCommand, opts BatchOpts) *BatchRun {
+	maxParallel := opts.MaxParallel
+	if maxParallel <= 0 {
+		maxParallel = runtime.NumCPU()
+	}
+
+	selected := selectShard(commands, opts.Shard, opts.Shards)
+
+	results := make(chan BatchResult, len(selected))
+	run := &BatchRun{Results: results, done: make(chan struct{})}
+
+	start := time.Now()
+
+	go func() {
+		defer close(results)
+		defer close(run.done)
+
+		ctx := context.Background()
+		if opts.GlobalTimeout > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, opts.GlobalTimeout)
+			defer cancel()
+		}
+
+		sem := make(chan struct{}, maxParallel)
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		aborted := false
+
+		for i, cmd := range selected {
+			mu.Lock()
+			stop := opts.FailFast && aborted
+			mu.Unlock()
+			if stop {
+				break
+			}
+
+			sem <- struct{}{}
+			wg.Add(1)
+			go func(i int, cmd BatchCommand) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				result := runBatchCommand(ctx, i, cmd)
+				results <- result
+
+				mu.Lock()
+				switch {
+				case result.TimedOut:
+					run.summary.TimedOut++
+				case result.ExitCode == 0:
+					run.summary.Passed++
+				default:
+					run.summary.Failed++
+				}
+				if opts.FailFast && (result.ExitCode != 0 || result.TimedOut) {
+					aborted = true
+				}
+				mu.Unlock()
+			}(i, cmd)
+		}
+
+		wg.Wait()
+		run.summary.Total = time.Since(start)
+	}()
+
+	return run
+}
+
+// selectShard mirrors Go's test runner: each command is assigned to
+// shard index hash(label) % shards, and only that shard's commands run.
+func selectShard(commands []BatchCommand, shard, shards int) []BatchCommand {
+	if shards <= 1 {
+		return commands
+	}
+
+	var selected []BatchCommand
+	for _, cmd := range commands {
+		h := fnv.New32a()
+		h.Write([]byte(cmd.Label))
+		if int(h.Sum32()%uint32(shards)) == shard {
+			selected = append(selected, cmd)
+		}
+	}
+	return selected
+}
+
+func runBatchCommand(parent context.Context, index int, cmd BatchCommand) BatchResult {
+	timeout := cmd.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(parent, timeout)
+	defer cancel()
+
+	start := time.Now()
+	execCmd := exec.CommandContext(ctx, "bash", "-c", cmd.Command)
+
+	var stdout, stderr bytes.Buffer
+	stdoutWriter := &prefixWriter{dest: &stdout, prefix: fmt.Sprintf("[%s] ", cmd.Label)}
+	stderrWriter := &prefixWriter{dest: &stderr, prefix: fmt.Sprintf("[%s] ", cmd.Label)}
+	execCmd.Stdout = stdoutWriter
+	execCmd.Stderr = stderrWriter
+
+	err := execCmd.Run()
+	stdoutWriter.Flush()
+	stderrWriter.Flush()
+
+	duration := time.Since(start)
+	timedOut := ctx.Err() == context.DeadlineExceeded
+
+	exitCode := 0
+	if err != nil {
+		switch {
+		case timedOut:
+			exitCode = 1
+		default:
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				exitCode = exitErr.ExitCode()
+			} else {
+				exitCode = 1
+			}
+		}
+	}
+
+	return BatchResult{
+		Index:    index,
+		Label:    cmd.Label,
+		ExitCode: exitCode,
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		Duration: duration,
+		TimedOut: timedOut,
+	}
+}
+
+// prefixWriter line-buffers writes and prepends prefix to every
+// complete line before forwarding it to dest.
+type prefixWriter struct {
+	dest   *bytes.Buffer
+	prefix string
+	buf    []byte
+}
+
+func (w *prefixWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	for {
+		idx := bytes.IndexByte(w.buf, '\n')
+		if idx < 0 {
+			break
+		}
+		w.dest.WriteString(w.prefix)
+		w.dest.Write(w.buf[:idx+1])
+		w.buf = w.buf[idx+1:]
+	}
+	return len(p), nil
+}
+
+// Flush forwards any trailing partial line that never ended in '\n'.
+func (w *prefixWriter) Flush() {
+	if len(w.buf) == 0 {
+		return
+	}
+	w.dest.WriteString(w.prefix)
+	w.dest.Write(w.buf)
+	w.buf = nil
+}
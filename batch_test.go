@@ -0,0 +1,93 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func drainBatch(run *BatchRun) []BatchResult {
+	var results []BatchResult
+	for r := range run.Results {
+		results = append(results, r)
+	}
+	return results
+}
+
+func TestExecuteShellBatch(t *testing.T) {
+	t.Run("runs commands concurrently and reports summary", func(t *testing.T) {
+		commands := []BatchCommand{
+			{Label: "a", Command: "echo a"},
+			{Label: "b", Command: "echo b"},
+			{Label: "c", Command: "exit 1"},
+		}
+
+		run := executeShellBatch(commands, BatchOpts{MaxParallel: 2})
+		results := drainBatch(run)
+		summary := run.Summary()
+
+		if len(results) != 3 {
+			t.Fatalf("expected 3 results, got %d", len(results))
+		}
+		if summary.Passed != 2 || summary.Failed != 1 {
+			t.Errorf("expected 2 passed / 1 failed, got %+v", summary)
+		}
+	})
+
+	t.Run("prefixes output with command label", func(t *testing.T) {
+		commands := []BatchCommand{{Label: "hello", Command: "echo hi"}}
+		run := executeShellBatch(commands, BatchOpts{})
+		results := drainBatch(run)
+
+		if !strings.Contains(results[0].Stdout, "[hello] hi") {
+			t.Errorf("expected prefixed stdout, got: %q", results[0].Stdout)
+		}
+	})
+
+	t.Run("per-command timeout", func(t *testing.T) {
+		commands := []BatchCommand{{Label: "slow", Command: "sleep 3", Timeout: 200 * time.Millisecond}}
+		run := executeShellBatch(commands, BatchOpts{})
+		results := drainBatch(run)
+		summary := run.Summary()
+
+		if !results[0].TimedOut {
+			t.Errorf("expected command to be marked as timed out")
+		}
+		if summary.TimedOut != 1 {
+			t.Errorf("expected summary.TimedOut = 1, got %d", summary.TimedOut)
+		}
+	})
+
+	t.Run("sharding only runs the selected shard", func(t *testing.T) {
+		commands := []BatchCommand{
+			{Label: "one", Command: "echo 1"},
+			{Label: "two", Command: "echo 2"},
+			{Label: "three", Command: "echo 3"},
+			{Label: "four", Command: "echo 4"},
+		}
+
+		var total int
+		for shard := 0; shard < 2; shard++ {
+			run := executeShellBatch(commands, BatchOpts{Shard: shard, Shards: 2})
+			total += len(drainBatch(run))
+		}
+
+		if total != len(commands) {
+			t.Errorf("expected shards to partition all commands exactly once, got %d total", total)
+		}
+	})
+
+	t.Run("fail-fast stops launching further commands", func(t *testing.T) {
+		commands := []BatchCommand{
+			{Label: "fail", Command: "exit 1"},
+			{Label: "ok", Command: "sleep 0.1 && echo ok"},
+		}
+
+		run := executeShellBatch(commands, BatchOpts{MaxParallel: 1, FailFast: true})
+		results := drainBatch(run)
+
+		if len(results) == 0 {
+			t.Fatalf("expected at least the failing command to report a result")
+		}
+	})
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+)
+
+// parseConversationArg parses the <id> argument to `agent resume` and
+// `agent fork`, exiting with a usage error if it's missing or invalid.
+func parseConversationArg(arg string) int64 {
+	if arg == "" {
+		log.Fatal("usage: agent resume|fork <id>")
+	}
+	id, err := strconv.ParseInt(arg, 10, 64)
+	if err != nil {
+		log.Fatalf("invalid conversation id %q: %v", arg, err)
+	}
+	return id
+}
+
+// runList implements `agent list`: one row per conversation, most
+// recently updated first, with cumulative token usage.
+func runList(store *ConversationStore) error {
+	conversations, err := store.listConversations()
+	if err != nil {
+		return fmt.Errorf("listing conversations: %w", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 2, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tAGENT\tTITLE\tPROMPT TOKENS\tCOMPLETION TOKENS\tUPDATED")
+	for _, c := range conversations {
+		title := c.Title
+		if title == "" {
+			title = "(untitled)"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%d\t%s\n",
+			c.ID, c.AgentName, title, c.PromptTokens, c.CompletionTokens, c.UpdatedAt.Format("2006-01-02 15:04"))
+	}
+	return w.Flush()
+}
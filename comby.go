@@ -0,0 +1,95 @@
+package main
+
+import "time"
+
+// CombyArgs are the arguments for the comby tool. Format selects
+// "text" (the default, comby's normal -stdout/-diff/-in-place output)
+// or "json" for structured per-match records via executeCombyJSON.
+type CombyArgs struct {
+	MatchTemplate   string `json:"match_template"`
+	RewriteTemplate string `json:"rewrite_template"`
+	Target          string `json:"target"`
+	MatchOnly       bool   `json:"match_only"`
+	InPlace         bool   `json:"in_place"`
+	Diff            bool   `json:"diff"`
+	Ext             string `json:"ext"`
+	Lang            string `json:"lang"`
+	Format          string `json:"format"`
+	Shards          int    `json:"shards"`
+	Shard           int    `json:"shard"`
+	Parallelism     int    `json:"parallelism"`
+}
+
+// executeComby runs the comby structural search/rewrite tool against
+// target. When rewriteTemplate is empty the run is match-only regardless
+// of matchOnly. ext and lang are mutually exclusive matcher selectors
+// (file extension vs. comby's named matcher). When inPlace rewrites a
+// single existing file, the target is read/written through activeFS and
+// the mutation is recorded to the ops ledger; directory targets are not
+// tracked since comby may touch many files in one invocation.
+func executeComby(matchTemplate, rewriteTemplate, target string, matchOnly, inPlace, diff bool, ext, lang string, timeout time.Duration) Result {
+	trackTarget := inPlace && rewriteTemplate != "" && !matchOnly
+
+	realTarget := target
+	var before []byte
+	var cleanup func()
+	if trackTarget {
+		if info, err := activeFS.Stat(target); err == nil && !info.IsDir() {
+			before, _ = activeFS.ReadFile(target)
+			rp, cu, err := materializeFile(target, true)
+			if err != nil {
+				trackTarget = false
+			} else {
+				realTarget = rp
+				cleanup = cu
+			}
+		} else {
+			trackTarget = false
+		}
+	}
+
+	args := []string{matchTemplate}
+
+	if rewriteTemplate != "" {
+		args = append(args, rewriteTemplate)
+	} else {
+		args = append(args, matchTemplate)
+		matchOnly = true
+	}
+
+	args = append(args, realTarget)
+
+	if ext != "" {
+		args = append(args, "-matcher", ext)
+	} else if lang != "" {
+		args = append(args, "-matcher", lang)
+	}
+
+	switch {
+	case matchOnly:
+		args = append(args, "-match-only", "-stdout")
+	case inPlace:
+		args = append(args, "-in-place")
+	case diff:
+		args = append(args, "-diff")
+	default:
+		args = append(args, "-stdout")
+	}
+
+	result := runCommand(timeout, "comby", args...)
+
+	if cleanup != nil {
+		cleanup()
+	}
+
+	if trackTarget && result.ExitCode == 0 {
+		if after, err := activeFS.ReadFile(target); err == nil {
+			recordOp("comby", target, string(before), string(after), map[string]string{
+				"match":   matchTemplate,
+				"rewrite": rewriteTemplate,
+			})
+		}
+	}
+
+	return result
+}
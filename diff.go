@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+var (
+	diffInsertStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	diffDeleteStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+)
+
+type diffOpKind int
+
+const (
+	diffEqual diffOpKind = iota
+	diffDelete
+	diffInsert
+)
+
+type diffOp struct {
+	kind diffOpKind
+	line string
+}
+
+// unifiedDiff produces a minimal unified-diff-style rendering of the
+// line-level changes between before and after, labeled with path.
+func unifiedDiff(before, after, path string) string {
+	ops := diffLines(strings.Split(before, "\n"), strings.Split(after, "\n"))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- a/%s\n", path)
+	fmt.Fprintf(&sb, "+++ b/%s\n", path)
+	for _, op := range ops {
+		switch op.kind {
+		case diffEqual:
+			sb.WriteString("  " + op.line + "\n")
+		case diffDelete:
+			sb.WriteString("- " + op.line + "\n")
+		case diffInsert:
+			sb.WriteString("+ " + op.line + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// coloredDiff renders the same unified diff as unifiedDiff, styled for
+// a terminal: additions in green, deletions in red, for display in the
+// huh-driven edit confirmation prompt.
+func coloredDiff(before, after, path string) string {
+	ops := diffLines(strings.Split(before, "\n"), strings.Split(after, "\n"))
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- a/%s\n", path)
+	fmt.Fprintf(&sb, "+++ b/%s\n", path)
+	for _, op := range ops {
+		switch op.kind {
+		case diffEqual:
+			sb.WriteString("  " + op.line + "\n")
+		case diffDelete:
+			sb.WriteString(diffDeleteStyle.Render("- "+op.line) + "\n")
+		case diffInsert:
+			sb.WriteString(diffInsertStyle.Render("+ "+op.line) + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// diffLines computes a line-level diff via a classic LCS dynamic
+// program. Adequate for the file sizes these tools operate on.
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	var ops []diffOp
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case a[i] == b[j]:
+			ops = append(ops, diffOp{diffEqual, a[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, diffOp{diffDelete, a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{diffInsert, b[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		ops = append(ops, diffOp{diffDelete, a[i]})
+	}
+	for ; j < m; j++ {
+		ops = append(ops, diffOp{diffInsert, b[j]})
+	}
+	return ops
+}
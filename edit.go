@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+)
+
+// ReadFileArgs are the arguments for the read_file tool.
+type ReadFileArgs struct {
+	Path string `json:"path"`
+}
+
+// WriteFileArgs are the arguments for the write_file tool.
+type WriteFileArgs struct {
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// ModifyFileArgs are the arguments for the modify_file tool. OldString
+// must match exactly one location in the file's current content.
+type ModifyFileArgs struct {
+	Path      string `json:"path"`
+	OldString string `json:"old_string"`
+	NewString string `json:"new_string"`
+}
+
+func (a *AgentState) handleReadFile(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Sprintf("Error reading %s: %v", path, err)
+	}
+	return string(data)
+}
+
+func (a *AgentState) handleWriteFile(path, content string) string {
+	before, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Sprintf("Error reading %s: %v", path, err)
+	}
+
+	approved, err := a.confirmEdit(path, string(before), content)
+	if err != nil {
+		return fmt.Sprintf("Edit prompt failed: %v", err)
+	}
+	if !approved {
+		return "Edit denied by user"
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Sprintf("Error creating parent directories for %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		return fmt.Sprintf("Error writing %s: %v", path, err)
+	}
+	return fmt.Sprintf("Wrote %s", path)
+}
+
+func (a *AgentState) handleModifyFile(path, oldString, newString string) string {
+	before, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Sprintf("Error reading %s: %v", path, err)
+	}
+
+	count := strings.Count(string(before), oldString)
+	switch count {
+	case 0:
+		return fmt.Sprintf("old_string not found in %s; expand the context so it matches exactly once", path)
+	case 1:
+		// exactly the replacement we want
+	default:
+		return fmt.Sprintf("old_string appears %d times in %s; expand the context so it matches exactly once", count, path)
+	}
+
+	after := strings.Replace(string(before), oldString, newString, 1)
+
+	approved, err := a.confirmEdit(path, string(before), after)
+	if err != nil {
+		return fmt.Sprintf("Edit prompt failed: %v", err)
+	}
+	if !approved {
+		return "Edit denied by user"
+	}
+
+	if err := os.WriteFile(path, []byte(after), 0644); err != nil {
+		return fmt.Sprintf("Error writing %s: %v", path, err)
+	}
+	return fmt.Sprintf("Modified %s", path)
+}
+
+// confirmEdit shows a colored diff of the proposed change and prompts
+// the user to approve it, the same permission gate handleBashCommand
+// applies before running a command.
+func (a *AgentState) confirmEdit(path, before, after string) (bool, error) {
+	fmt.Print(coloredDiff(before, after, path))
+
+	approve := true
+	err := huh.NewConfirm().
+		Title(fmt.Sprintf("Apply this edit to %s?", path)).
+		Value(&approve).
+		Run()
+	if err != nil {
+		return false, err
+	}
+	return approve, nil
+}
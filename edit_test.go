@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestHandleModifyFileMatchCount(t *testing.T) {
+	a := &AgentState{}
+
+	os.MkdirAll("test_data", 0755)
+	defer os.RemoveAll("test_data")
+
+	t.Run("file does not exist", func(t *testing.T) {
+		got := a.handleModifyFile("test_data/does_not_exist.txt", "old", "new")
+		if !strings.Contains(got, "Error reading") {
+			t.Errorf("handleModifyFile() = %q, want an error reading the missing file", got)
+		}
+	})
+
+	t.Run("old_string not found", func(t *testing.T) {
+		path := "test_data/modify_zero.txt"
+		os.WriteFile(path, []byte("hello world\n"), 0644)
+
+		got := a.handleModifyFile(path, "goodbye", "hi")
+		if !strings.Contains(got, "not found") {
+			t.Errorf("handleModifyFile() = %q, want a not-found message", got)
+		}
+
+		after, _ := os.ReadFile(path)
+		if string(after) != "hello world\n" {
+			t.Errorf("file was modified despite old_string not matching: %q", after)
+		}
+	})
+
+	t.Run("old_string appears multiple times", func(t *testing.T) {
+		path := "test_data/modify_multi.txt"
+		os.WriteFile(path, []byte("foo\nfoo\nfoo\n"), 0644)
+
+		got := a.handleModifyFile(path, "foo", "bar")
+		if !strings.Contains(got, "appears 3 times") {
+			t.Errorf("handleModifyFile() = %q, want a message reporting 3 occurrences", got)
+		}
+
+		after, _ := os.ReadFile(path)
+		if string(after) != "foo\nfoo\nfoo\n" {
+			t.Errorf("file was modified despite old_string matching more than once: %q", after)
+		}
+	})
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RateLimitError is a 429 response. RetryAfter is parsed from the
+// wire's Retry-After header when the provider sends one, and is zero
+// otherwise.
+type RateLimitError struct {
+	RetryAfter time.Duration
+	Body       string
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("rate limited: %s", e.Body)
+}
+
+// ServerError is a 5xx response, retried today with the same
+// exponential backoff callStreamWithRetry already used before this
+// taxonomy existed.
+type ServerError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *ServerError) Error() string {
+	return fmt.Sprintf("server error (status %d): %s", e.StatusCode, e.Body)
+}
+
+// ContextLengthError means the request exceeded the model's context
+// window. It's retried after compacting the oldest messages in the
+// conversation into a single summary note, rather than by waiting.
+type ContextLengthError struct {
+	Body string
+}
+
+func (e *ContextLengthError) Error() string {
+	return fmt.Sprintf("context length exceeded: %s", e.Body)
+}
+
+// AuthError is a 401/403 response. It never succeeds on retry, so
+// callers should fail fast instead of burning the retry budget.
+type AuthError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *AuthError) Error() string {
+	return fmt.Sprintf("authentication error (status %d): %s", e.StatusCode, e.Body)
+}
+
+// NetworkError wraps a transport-level failure (connection refused,
+// DNS failure, timeout) that never reached the provider at all.
+type NetworkError struct {
+	Err error
+}
+
+func (e *NetworkError) Error() string { return fmt.Sprintf("network error: %v", e.Err) }
+func (e *NetworkError) Unwrap() error { return e.Err }
+
+// classifyHTTPError turns a provider's raw HTTP failure into the typed
+// error taxonomy above, so shouldRetry and the UI can dispatch on cause
+// instead of every caller getting back the same flat ProviderError.
+func classifyHTTPError(statusCode int, body string, header http.Header) error {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return &RateLimitError{RetryAfter: parseRetryAfter(header), Body: body}
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return &AuthError{StatusCode: statusCode, Body: body}
+	case statusCode == http.StatusBadRequest && isContextLengthError(body):
+		return &ContextLengthError{Body: body}
+	case statusCode >= 500 && statusCode < 600:
+		return &ServerError{StatusCode: statusCode, Body: body}
+	default:
+		return &ProviderError{StatusCode: statusCode, Body: body}
+	}
+}
+
+// parseRetryAfter reads a Retry-After header expressed in seconds.
+// Providers that send an HTTP-date instead are treated as not having
+// specified a delay; the caller falls back to its own default.
+func parseRetryAfter(header http.Header) time.Duration {
+	if header == nil {
+		return 0
+	}
+	secs, err := strconv.Atoi(header.Get("Retry-After"))
+	if err != nil {
+		return 0
+	}
+	return time.Duration(secs) * time.Second
+}
+
+// isContextLengthError sniffs a 400 response body for the phrasing
+// OpenAI and Anthropic both use for a context-window overflow.
+func isContextLengthError(body string) bool {
+	lower := strings.ToLower(body)
+	return strings.Contains(lower, "context_length_exceeded") || strings.Contains(lower, "maximum context length")
+}
+
+// retryDecision is classifyRetry's verdict on a failed provider call.
+type retryDecision struct {
+	retry   bool
+	wait    time.Duration // overrides the default exponential backoff when non-zero
+	compact bool          // conversation history should be compacted before retrying
+}
+
+// classifyRetry decides how callStreamWithRetry should react to err:
+// wait for a rate limit's Retry-After, back off for a server or
+// network error, compact history for a context-length overflow, or
+// give up immediately for an auth failure.
+func classifyRetry(err error) retryDecision {
+	switch e := err.(type) {
+	case *RateLimitError:
+		return retryDecision{retry: true, wait: e.RetryAfter}
+	case *ServerError:
+		return retryDecision{retry: true}
+	case *NetworkError:
+		return retryDecision{retry: true}
+	case *ContextLengthError:
+		return retryDecision{retry: true, compact: true}
+	case *AuthError:
+		return retryDecision{retry: false}
+	default:
+		return retryDecision{retry: false}
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestClassifyHTTPError(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		body       string
+		header     http.Header
+		want       interface{}
+	}{
+		{name: "rate limit", statusCode: http.StatusTooManyRequests, body: "slow down", want: &RateLimitError{}},
+		{name: "unauthorized", statusCode: http.StatusUnauthorized, body: "bad key", want: &AuthError{}},
+		{name: "forbidden", statusCode: http.StatusForbidden, body: "nope", want: &AuthError{}},
+		{name: "context length", statusCode: http.StatusBadRequest, body: `{"error":"context_length_exceeded"}`, want: &ContextLengthError{}},
+		{name: "other 400", statusCode: http.StatusBadRequest, body: "bad request", want: &ProviderError{}},
+		{name: "server error", statusCode: http.StatusInternalServerError, body: "oops", want: &ServerError{}},
+		{name: "server error 503", statusCode: http.StatusServiceUnavailable, body: "busy", want: &ServerError{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classifyHTTPError(tt.statusCode, tt.body, tt.header)
+			switch tt.want.(type) {
+			case *RateLimitError:
+				if _, ok := err.(*RateLimitError); !ok {
+					t.Errorf("classifyHTTPError() = %T, want *RateLimitError", err)
+				}
+			case *AuthError:
+				if _, ok := err.(*AuthError); !ok {
+					t.Errorf("classifyHTTPError() = %T, want *AuthError", err)
+				}
+			case *ContextLengthError:
+				if _, ok := err.(*ContextLengthError); !ok {
+					t.Errorf("classifyHTTPError() = %T, want *ContextLengthError", err)
+				}
+			case *ServerError:
+				if _, ok := err.(*ServerError); !ok {
+					t.Errorf("classifyHTTPError() = %T, want *ServerError", err)
+				}
+			case *ProviderError:
+				if _, ok := err.(*ProviderError); !ok {
+					t.Errorf("classifyHTTPError() = %T, want *ProviderError", err)
+				}
+			}
+		})
+	}
+
+	t.Run("rate limit parses Retry-After", func(t *testing.T) {
+		header := http.Header{"Retry-After": []string{"30"}}
+		err := classifyHTTPError(http.StatusTooManyRequests, "slow down", header)
+		rle, ok := err.(*RateLimitError)
+		if !ok {
+			t.Fatalf("classifyHTTPError() = %T, want *RateLimitError", err)
+		}
+		if rle.RetryAfter != 30*time.Second {
+			t.Errorf("RetryAfter = %v, want 30s", rle.RetryAfter)
+		}
+	})
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{name: "nil header", header: nil, want: 0},
+		{name: "missing header", header: http.Header{}, want: 0},
+		{name: "seconds", header: http.Header{"Retry-After": []string{"5"}}, want: 5 * time.Second},
+		{name: "http-date is not a delay", header: http.Header{"Retry-After": []string{"Wed, 21 Oct 2026 07:28:00 GMT"}}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseRetryAfter(tt.header); got != tt.want {
+				t.Errorf("parseRetryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsContextLengthError(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want bool
+	}{
+		{name: "openai phrasing", body: `{"error":{"code":"context_length_exceeded"}}`, want: true},
+		{name: "anthropic phrasing", body: "prompt is too long: maximum context length is 200000 tokens", want: true},
+		{name: "unrelated 400", body: `{"error":"invalid_api_key"}`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isContextLengthError(tt.body); got != tt.want {
+				t.Errorf("isContextLengthError(%q) = %v, want %v", tt.body, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyRetry(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantRetry   bool
+		wantCompact bool
+	}{
+		{name: "rate limit retries", err: &RateLimitError{RetryAfter: 10 * time.Second}, wantRetry: true},
+		{name: "server error retries", err: &ServerError{StatusCode: 500}, wantRetry: true},
+		{name: "network error retries", err: &NetworkError{}, wantRetry: true},
+		{name: "context length retries and compacts", err: &ContextLengthError{}, wantRetry: true, wantCompact: true},
+		{name: "auth error does not retry", err: &AuthError{StatusCode: 401}, wantRetry: false},
+		{name: "unknown error does not retry", err: &ProviderError{StatusCode: 400}, wantRetry: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := classifyRetry(tt.err)
+			if got.retry != tt.wantRetry {
+				t.Errorf("classifyRetry().retry = %v, want %v", got.retry, tt.wantRetry)
+			}
+			if got.compact != tt.wantCompact {
+				t.Errorf("classifyRetry().compact = %v, want %v", got.compact, tt.wantCompact)
+			}
+		})
+	}
+
+	t.Run("rate limit carries RetryAfter through as wait", func(t *testing.T) {
+		got := classifyRetry(&RateLimitError{RetryAfter: 42 * time.Second})
+		if got.wait != 42*time.Second {
+			t.Errorf("classifyRetry().wait = %v, want 42s", got.wait)
+		}
+	})
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/creack/pty"
+)
+
+// ErrTimeout is returned by executeExpect when a step's pattern was not
+// observed within its timeout.
+var ErrTimeout = errors.New("expect: timed out waiting for pattern")
+
+// ErrClosed is returned by executeExpect when the child process exited
+// before a step's pattern was matched.
+var ErrClosed = errors.New("expect: child closed before pattern matched")
+
+// MatchMode selects how an ExpectStep.Pattern is interpreted.
+type MatchMode int
+
+const (
+	MatchLiteral MatchMode = iota
+	MatchRegex
+)
+
+// ExpectStep is either a "wait for Pattern" step (Send empty) or a
+// "write Send to stdin" step (Pattern empty). Captures names submatches
+// from a regex Pattern into the result's Captures map.
+type ExpectStep struct {
+	Pattern  string
+	Mode     MatchMode
+	Send     string
+	Timeout  time.Duration
+	Captures []string
+}
+
+// ExpectResult is the outcome of running an executeExpect script.
+type ExpectResult struct {
+	ExitCode   int
+	Transcript string
+	Captures   map[string]string
+	Err        error
+}
+
+// ExpectStepArgs is the JSON shape of one ExpectStep in the expect
+// tool's arguments: Regex selects MatchRegex over the MatchLiteral
+// default, and TimeoutSeconds of zero falls back to the script's
+// overall timeout.
+type ExpectStepArgs struct {
+	Pattern        string   `json:"pattern"`
+	Regex          bool     `json:"regex"`
+	Send           string   `json:"send"`
+	TimeoutSeconds int      `json:"timeout_seconds"`
+	Captures       []string `json:"captures"`
+}
+
+// ExpectArgs are the arguments for the expect tool.
+type ExpectArgs struct {
+	Command        string           `json:"command"`
+	Steps          []ExpectStepArgs `json:"steps"`
+	TimeoutSeconds int              `json:"timeout_seconds"`
+}
+
+// executeExpect spawns command under a PTY and drives it through steps in
+// order: steps with a Pattern block until that pattern appears in the
+// transcript (or the step's Timeout elapses), steps with Send write that
+// text (plus a trailing newline) to the child's stdin.
+func executeExpect(command string, steps []ExpectStep, timeout time.Duration) ExpectResult {
+	cmd := exec.Command("bash", "-c", command)
+
+	ptmx, err := pty.Start(cmd)
+	if err != nil {
+		return ExpectResult{ExitCode: 1, Err: err}
+	}
+	defer ptmx.Close()
+
+	var transcript strings.Builder
+	var mu sync.Mutex
+	captures := make(map[string]string)
+
+	output := make(chan string, 64)
+	done := make(chan struct{})
+
+	go func() {
+		reader := bufio.NewReader(ptmx)
+		buf := make([]byte, 4096)
+		for {
+			n, err := reader.Read(buf)
+			if n > 0 {
+				chunk := string(buf[:n])
+				mu.Lock()
+				transcript.WriteString(chunk)
+				mu.Unlock()
+				output <- chunk
+			}
+			if err != nil {
+				close(done)
+				return
+			}
+		}
+	}()
+
+	var runErr error
+	for _, step := range steps {
+		stepTimeout := step.Timeout
+		if stepTimeout == 0 {
+			stepTimeout = timeout
+		}
+
+		if step.Pattern != "" {
+			if err := waitForPattern(step, output, done, stepTimeout, captures); err != nil {
+				runErr = err
+				break
+			}
+		}
+
+		if step.Send != "" {
+			if _, err := ptmx.WriteString(step.Send + "\n"); err != nil {
+				runErr = err
+				break
+			}
+		}
+	}
+
+	// Finishing the step loop -- even with runErr == nil -- doesn't mean
+	// the child exited on its own: a script with no "exit"/EOF step (or
+	// one whose pattern step timed out or closed early) can leave it
+	// sitting there forever. Kill unconditionally before Wait so this
+	// call can't hang past the caller's timeout regardless of how the
+	// loop finished; killing an already-exited process is a harmless
+	// no-op error we ignore.
+	cmd.Process.Kill()
+
+	exitCode := 0
+	waitErr := cmd.Wait()
+	if waitErr != nil {
+		if exitErr, ok := waitErr.(*exec.ExitError); ok {
+			exitCode = exitErr.ExitCode()
+		} else {
+			exitCode = 1
+		}
+	}
+
+	mu.Lock()
+	full := transcript.String()
+	mu.Unlock()
+
+	if runErr != nil {
+		return ExpectResult{ExitCode: 1, Transcript: full, Captures: captures, Err: runErr}
+	}
+
+	return ExpectResult{ExitCode: exitCode, Transcript: full, Captures: captures}
+}
+
+func waitForPattern(step ExpectStep, output <-chan string, done <-chan struct{}, timeout time.Duration, captures map[string]string) error {
+	var re *regexp.Regexp
+	if step.Mode == MatchRegex {
+		compiled, err := regexp.Compile(step.Pattern)
+		if err != nil {
+			return fmt.Errorf("expect: invalid regex pattern %q: %w", step.Pattern, err)
+		}
+		re = compiled
+	}
+
+	var seen strings.Builder
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case chunk, ok := <-output:
+			if !ok {
+				return ErrClosed
+			}
+			seen.WriteString(chunk)
+
+			if step.Mode == MatchRegex {
+				if m := re.FindStringSubmatch(seen.String()); m != nil {
+					for i, name := range re.SubexpNames() {
+						if name != "" && i < len(m) {
+							captures[name] = m[i]
+						}
+					}
+					return nil
+				}
+			} else if strings.Contains(seen.String(), step.Pattern) {
+				return nil
+			}
+
+		case <-done:
+			// Drain any remaining buffered chunks before giving up.
+			select {
+			case chunk := <-output:
+				seen.WriteString(chunk)
+				if matchesPattern(step, re, seen.String()) {
+					return nil
+				}
+			default:
+			}
+			return ErrClosed
+
+		case <-timer.C:
+			return ErrTimeout
+		}
+	}
+}
+
+func matchesPattern(step ExpectStep, re *regexp.Regexp, seen string) bool {
+	if step.Mode == MatchRegex {
+		return re.MatchString(seen)
+	}
+	return strings.Contains(seen, step.Pattern)
+}
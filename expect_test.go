@@ -0,0 +1,73 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecuteExpect(t *testing.T) {
+	t.Run("echo roundtrip", func(t *testing.T) {
+		steps := []ExpectStep{
+			// bash's default PS1 ends in "#" for root and "$" for
+			// everyone else; match either rather than assuming
+			// non-root, which hangs this step for the full timeout
+			// under a root-run sandbox (this one included).
+			{Pattern: `[$#]\s*$`, Mode: MatchRegex, Timeout: 5 * time.Second},
+			{Send: "echo hello-expect"},
+			{Pattern: "hello-expect", Mode: MatchLiteral, Timeout: 5 * time.Second},
+			{Send: "exit"},
+		}
+
+		result := executeExpect("bash --norc -i", steps, 10*time.Second)
+		if result.Err != nil {
+			t.Fatalf("executeExpect() error = %v", result.Err)
+		}
+		if !strings.Contains(result.Transcript, "hello-expect") {
+			t.Errorf("expected transcript to contain echoed text, got: %s", result.Transcript)
+		}
+	})
+
+	t.Run("bidirectional cat", func(t *testing.T) {
+		steps := []ExpectStep{
+			{Send: "line one"},
+			{Pattern: "line one", Mode: MatchLiteral, Timeout: 5 * time.Second},
+			{Send: "line two"},
+			{Pattern: "line two", Mode: MatchLiteral, Timeout: 5 * time.Second},
+		}
+
+		result := executeExpect("cat", steps, 10*time.Second)
+		if result.Err != nil {
+			t.Fatalf("executeExpect() error = %v", result.Err)
+		}
+		if !strings.Contains(result.Transcript, "line one") || !strings.Contains(result.Transcript, "line two") {
+			t.Errorf("expected both lines echoed back, got: %s", result.Transcript)
+		}
+	})
+
+	t.Run("timeout when pattern never appears", func(t *testing.T) {
+		steps := []ExpectStep{
+			{Pattern: "this-will-never-appear", Mode: MatchLiteral, Timeout: 200 * time.Millisecond},
+		}
+
+		result := executeExpect("sleep 5", steps, 10*time.Second)
+		if result.Err != ErrTimeout {
+			t.Errorf("expected ErrTimeout, got %v", result.Err)
+		}
+	})
+
+	t.Run("captures named submatches", func(t *testing.T) {
+		steps := []ExpectStep{
+			{Send: "version=1.2.3"},
+			{Pattern: `version=(?P<version>[0-9.]+)`, Mode: MatchRegex, Timeout: 5 * time.Second},
+		}
+
+		result := executeExpect("cat", steps, 10*time.Second)
+		if result.Err != nil {
+			t.Fatalf("executeExpect() error = %v", result.Err)
+		}
+		if result.Captures["version"] != "1.2.3" {
+			t.Errorf("expected captured version 1.2.3, got: %v", result.Captures)
+		}
+	})
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+// FS abstracts the filesystem operations used by the tool executors, so
+// tests can swap in an in-memory implementation instead of touching the
+// real disk.
+type FS interface {
+	ReadFile(name string) ([]byte, error)
+	WriteFile(name string, data []byte, perm os.FileMode) error
+	Stat(name string) (fs.FileInfo, error)
+	Remove(name string) error
+	MkdirAll(path string, perm os.FileMode) error
+}
+
+// activeFS is the filesystem used by executeSed, executeTodo, and the
+// file-writing paths of executeComby. Tests may swap it for a
+// *MemMapFS to run hermetically; production code leaves it as OsFS.
+var activeFS FS = OsFS{}
+
+// OsFS implements FS against the real operating system filesystem.
+type OsFS struct{}
+
+func (OsFS) ReadFile(name string) ([]byte, error) { return os.ReadFile(name) }
+
+func (OsFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	return os.WriteFile(name, data, perm)
+}
+
+func (OsFS) Stat(name string) (fs.FileInfo, error) { return os.Stat(name) }
+
+func (OsFS) Remove(name string) error { return os.Remove(name) }
+
+func (OsFS) MkdirAll(path string, perm os.FileMode) error { return os.MkdirAll(path, perm) }
+
+// MemMapFS is an in-memory FS implementation for hermetic tests.
+type MemMapFS struct {
+	mu    sync.RWMutex
+	files map[string][]byte
+}
+
+func NewMemMapFS() *MemMapFS {
+	return &MemMapFS{files: make(map[string][]byte)}
+}
+
+func (m *MemMapFS) ReadFile(name string) ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	data, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	out := make([]byte, len(data))
+	copy(out, data)
+	return out, nil
+}
+
+func (m *MemMapFS) WriteFile(name string, data []byte, perm os.FileMode) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.files == nil {
+		m.files = make(map[string][]byte)
+	}
+	cp := make([]byte, len(data))
+	copy(cp, data)
+	m.files[name] = cp
+	return nil
+}
+
+func (m *MemMapFS) Stat(name string) (fs.FileInfo, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	data, ok := m.files[name]
+	if !ok {
+		return nil, &fs.PathError{Op: "stat", Path: name, Err: fs.ErrNotExist}
+	}
+	return memFileInfo{name: name, size: int64(len(data))}, nil
+}
+
+func (m *MemMapFS) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.files[name]; !ok {
+		return &fs.PathError{Op: "remove", Path: name, Err: fs.ErrNotExist}
+	}
+	delete(m.files, name)
+	return nil
+}
+
+func (m *MemMapFS) MkdirAll(path string, perm os.FileMode) error { return nil }
+
+type memFileInfo struct {
+	name string
+	size int64
+}
+
+func (fi memFileInfo) Name() string       { return fi.name }
+func (fi memFileInfo) Size() int64        { return fi.size }
+func (fi memFileInfo) Mode() fs.FileMode  { return 0644 }
+func (fi memFileInfo) ModTime() time.Time { return time.Time{} }
+func (fi memFileInfo) IsDir() bool        { return false }
+func (fi memFileInfo) Sys() interface{}   { return nil }
+
+// materializeFile makes path readable by an external binary. Against
+// OsFS this is a no-op. Against an in-memory FS, path's content is
+// copied into a scratch temp file whose real path is returned; when
+// mutate is true, calling the returned cleanup copies the temp file's
+// (possibly modified) content back into activeFS before removing it.
+func materializeFile(path string, mutate bool) (realPath string, cleanup func(), err error) {
+	if _, ok := activeFS.(OsFS); ok {
+		return path, func() {}, nil
+	}
+
+	data, err := activeFS.ReadFile(path)
+	if err != nil {
+		return "", nil, err
+	}
+
+	tmp, err := os.CreateTemp("", "agent-fs-*")
+	if err != nil {
+		return "", nil, err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return "", nil, err
+	}
+	tmp.Close()
+
+	cleanup = func() {
+		if mutate {
+			if out, err := os.ReadFile(tmp.Name()); err == nil {
+				activeFS.WriteFile(path, out, 0644)
+			}
+		}
+		os.Remove(tmp.Name())
+	}
+
+	return tmp.Name(), cleanup, nil
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// withMemFS swaps activeFS for a fresh in-memory filesystem for the
+// duration of fn, and redirects the ops ledger and dry-run approvals
+// store (which stay real-disk-only, see ledger.go) into t.TempDir()
+// so hermetic tests don't leave files behind in ./.agent/. Both are
+// restored once fn returns.
+func withMemFS(t *testing.T, fn func(mem *MemMapFS)) {
+	t.Helper()
+
+	previousFS := activeFS
+	mem := NewMemMapFS()
+	activeFS = mem
+	defer func() { activeFS = previousFS }()
+
+	previousOpsLedger, previousApprovals := opsLedgerPath, dryRunApprovalsPath
+	dir := t.TempDir()
+	opsLedgerPath = filepath.Join(dir, "ops.log")
+	dryRunApprovalsPath = filepath.Join(dir, "dryrun-approvals.log")
+	defer func() { opsLedgerPath, dryRunApprovalsPath = previousOpsLedger, previousApprovals }()
+
+	fn(mem)
+}
+
+func TestMemMapFSBasics(t *testing.T) {
+	mem := NewMemMapFS()
+
+	if _, err := mem.ReadFile("missing.txt"); err == nil {
+		t.Fatalf("expected error reading nonexistent file")
+	}
+
+	if err := mem.WriteFile("a.txt", []byte("hello"), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	data, err := mem.ReadFile("a.txt")
+	if err != nil || string(data) != "hello" {
+		t.Fatalf("ReadFile = %q, %v, want hello, nil", data, err)
+	}
+
+	if _, err := mem.Stat("a.txt"); err != nil {
+		t.Errorf("Stat failed for existing file: %v", err)
+	}
+
+	if err := mem.Remove("a.txt"); err != nil {
+		t.Errorf("Remove failed: %v", err)
+	}
+	if _, err := mem.ReadFile("a.txt"); err == nil {
+		t.Errorf("expected ReadFile to fail after Remove")
+	}
+}
+
+func TestExecuteTodoHermetic(t *testing.T) {
+	withMemFS(t, func(mem *MemMapFS) {
+		file := "todo.md"
+
+		if res := executeTodo("add", file, "Write hermetic tests", 10*time.Second); res.ExitCode != 0 {
+			t.Fatalf("add failed: %v", res.Stderr)
+		}
+
+		if _, err := mem.ReadFile(file); err != nil {
+			t.Fatalf("expected todo file to live in the in-memory FS, got: %v", err)
+		}
+
+		res := executeTodo("read", file, "", 10*time.Second)
+		if res.ExitCode != 0 {
+			t.Fatalf("read failed: %v", res.Stderr)
+		}
+		if !strings.Contains(res.Stdout, "Write hermetic tests") {
+			t.Errorf("expected task in todo content, got: %s", res.Stdout)
+		}
+	})
+}
+
+func TestExecuteSedHermetic(t *testing.T) {
+	withMemFS(t, func(mem *MemMapFS) {
+		resetSedCache()
+		file := "hermetic.txt"
+		mem.WriteFile(file, []byte("Hello World"), 0644)
+
+		if res := executeSed(file, "World", "Mem", true, 10*time.Second); res.ExitCode != 0 {
+			t.Fatalf("dry-run failed: %v", res.Stderr)
+		}
+		if res := executeSed(file, "World", "Mem", false, 10*time.Second); res.ExitCode != 0 {
+			t.Fatalf("apply failed: %v", res.Stderr)
+		}
+
+		data, err := mem.ReadFile(file)
+		if err != nil {
+			t.Fatalf("expected file to remain in memory: %v", err)
+		}
+		if !strings.Contains(string(data), "Mem") {
+			t.Errorf("expected in-memory file to reflect sed replacement, got: %s", data)
+		}
+	})
+}
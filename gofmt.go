@@ -0,0 +1,64 @@
+package main
+
+import (
+	"runtime"
+	"time"
+)
+
+// GofmtMode selects how executeGofmtMode formats files: by shelling out
+// to the gofmt binary, or in-process via go/format.
+type GofmtMode int
+
+const (
+	GofmtShell GofmtMode = iota
+	GofmtInProcess
+)
+
+// GofmtArgs are the arguments for the gofmt tool. Format selects "text"
+// (the default, gofmt's normal -l/-d/-w output) or "json" for
+// structured per-file records via executeGofmtJSON. InProcess selects
+// formatGoInProcess over shelling out to the gofmt binary.
+type GofmtArgs struct {
+	Target      string `json:"target"`
+	List        bool   `json:"list"`
+	Diff        bool   `json:"diff"`
+	Write       bool   `json:"write"`
+	InProcess   bool   `json:"in_process"`
+	Format      string `json:"format"`
+	Shards      int    `json:"shards"`
+	Shard       int    `json:"shard"`
+	Parallelism int    `json:"parallelism"`
+}
+
+// executeGofmt shells out to gofmt against target (a file or directory).
+// list, diff and write mirror gofmt's -l, -d and -w flags respectively.
+func executeGofmt(target string, list, diff, write bool, timeout time.Duration) Result {
+	return executeGofmtMode(target, list, diff, write, GofmtShell, timeout)
+}
+
+// executeGofmtMode is executeGofmt with an explicit mode: GofmtShell
+// preserves the original subprocess behavior, GofmtInProcess formats via
+// formatGoInProcess instead, avoiding a dependency on the gofmt binary.
+func executeGofmtMode(target string, list, diff, write bool, mode GofmtMode, timeout time.Duration) Result {
+	if mode == GofmtInProcess {
+		return formatGoInProcess(target, list, diff, write, runtime.NumCPU())
+	}
+
+	var args []string
+	if list {
+		args = append(args, "-l")
+	}
+	if diff {
+		args = append(args, "-d")
+	}
+	if write {
+		args = append(args, "-w")
+	}
+
+	if target == "" {
+		target = "."
+	}
+	args = append(args, target)
+
+	return runCommand(timeout, "gofmt", args...)
+}
@@ -0,0 +1,167 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// GofmtFileError is a single file's formatting failure, returned
+// separately per file rather than merged into one stderr blob.
+type GofmtFileError struct {
+	Path string
+	Err  error
+}
+
+// GofmtFileResult is the per-file outcome of formatting one Go source
+// file in-process.
+type GofmtFileResult struct {
+	Path        string
+	NeedsFormat bool
+	Formatted   []byte
+	Diff        string
+}
+
+// formatGoInProcess walks target (a file or directory), reformats every
+// .go file found via go/format.Source, and returns a Result whose
+// Stdout mirrors gofmt's -l/-d/-w semantics for list/diff/write
+// respectively. Per-file parse/format errors are collected as
+// GofmtFileError rather than merged into a single stderr blob.
+func formatGoInProcess(target string, list, diff, write bool, workers int) Result {
+	if target == "" {
+		target = "."
+	}
+	if workers <= 0 {
+		workers = 1
+	}
+
+	files, err := collectGoFiles(target)
+	if err != nil {
+		return Result{Stderr: err.Error(), ExitCode: 1}
+	}
+
+	results, fileErrors := formatGoFiles(files, workers)
+
+	var stdout, stderr strings.Builder
+	for _, r := range results {
+		if !r.NeedsFormat {
+			continue
+		}
+		switch {
+		case list:
+			fmt.Fprintln(&stdout, r.Path)
+		case diff:
+			stdout.WriteString(r.Diff)
+		case write:
+			if err := os.WriteFile(r.Path, r.Formatted, 0644); err != nil {
+				fileErrors = append(fileErrors, GofmtFileError{Path: r.Path, Err: err})
+			}
+		}
+	}
+
+	for _, fe := range fileErrors {
+		fmt.Fprintf(&stderr, "%s: %v\n", fe.Path, fe.Err)
+	}
+
+	exitCode := 0
+	if len(fileErrors) > 0 {
+		exitCode = 1
+	}
+
+	return Result{Stdout: stdout.String(), Stderr: stderr.String(), ExitCode: exitCode}
+}
+
+func formatGoFiles(files []string, workers int) ([]GofmtFileResult, []GofmtFileError) {
+	type outcome struct {
+		result GofmtFileResult
+		err    *GofmtFileError
+	}
+
+	jobs := make(chan string)
+	outcomes := make(chan outcome)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				res, ferr := formatGoFile(path)
+				outcomes <- outcome{result: res, err: ferr}
+			}
+		}()
+	}
+
+	go func() {
+		for _, f := range files {
+			jobs <- f
+		}
+		close(jobs)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	var results []GofmtFileResult
+	var fileErrors []GofmtFileError
+	for o := range outcomes {
+		if o.err != nil {
+			fileErrors = append(fileErrors, *o.err)
+			continue
+		}
+		results = append(results, o.result)
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Path < results[j].Path })
+	sort.Slice(fileErrors, func(i, j int) bool { return fileErrors[i].Path < fileErrors[j].Path })
+
+	return results, fileErrors
+}
+
+func collectGoFiles(target string) ([]string, error) {
+	info, err := os.Stat(target)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		return []string{target}, nil
+	}
+
+	var files []string
+	err = filepath.Walk(target, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(path, ".go") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+func formatGoFile(path string) (GofmtFileResult, *GofmtFileError) {
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return GofmtFileResult{}, &GofmtFileError{Path: path, Err: err}
+	}
+
+	formatted, err := format.Source(original)
+	if err != nil {
+		return GofmtFileResult{}, &GofmtFileError{Path: path, Err: err}
+	}
+
+	result := GofmtFileResult{Path: path, NeedsFormat: !bytes.Equal(original, formatted), Formatted: formatted}
+	if result.NeedsFormat {
+		result.Diff = unifiedDiff(string(original), string(formatted), path)
+	}
+	return result, nil
+}
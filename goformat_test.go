@@ -0,0 +1,85 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFormatGoInProcess(t *testing.T) {
+	os.MkdirAll("test_data/goformat", 0755)
+	defer os.RemoveAll("test_data/goformat")
+
+	unformatted := "package main\n\nimport(\n\"fmt\"\n)\n\nfunc main( ) {\nfmt.Println(\"hi\")\n}"
+	testFile := "test_data/goformat/unformatted.go"
+	if err := os.WriteFile(testFile, []byte(unformatted), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	t.Run("list files that need formatting", func(t *testing.T) {
+		result := formatGoInProcess(testFile, true, false, false, 2)
+		if result.ExitCode != 0 {
+			t.Fatalf("list failed: exitCode = %v, stderr = %s", result.ExitCode, result.Stderr)
+		}
+		if !strings.Contains(result.Stdout, "unformatted.go") {
+			t.Errorf("expected unformatted.go in list output, got: %s", result.Stdout)
+		}
+	})
+
+	t.Run("diff without writing", func(t *testing.T) {
+		before, _ := os.ReadFile(testFile)
+		result := formatGoInProcess(testFile, false, true, false, 2)
+		if result.ExitCode != 0 {
+			t.Fatalf("diff failed: exitCode = %v, stderr = %s", result.ExitCode, result.Stderr)
+		}
+		if !strings.Contains(result.Stdout, "import") {
+			t.Errorf("expected diff to mention import reformatting, got: %s", result.Stdout)
+		}
+		after, _ := os.ReadFile(testFile)
+		if string(before) != string(after) {
+			t.Errorf("diff mode must not modify the file")
+		}
+	})
+
+	t.Run("write formats in place", func(t *testing.T) {
+		result := formatGoInProcess(testFile, false, false, true, 2)
+		if result.ExitCode != 0 {
+			t.Fatalf("write failed: exitCode = %v, stderr = %s", result.ExitCode, result.Stderr)
+		}
+
+		formatted, err := os.ReadFile(testFile)
+		if err != nil {
+			t.Fatalf("failed to read formatted file: %v", err)
+		}
+		if !strings.Contains(string(formatted), "import (") {
+			t.Errorf("expected file to be reformatted, got: %s", formatted)
+		}
+
+		idempotent := formatGoInProcess(testFile, true, false, false, 2)
+		if strings.Contains(idempotent.Stdout, "unformatted.go") {
+			t.Errorf("expected already-formatted file to not need reformatting")
+		}
+	})
+
+	t.Run("syntax error surfaces as a structured per-file error", func(t *testing.T) {
+		invalidFile := "test_data/goformat/invalid.go"
+		os.WriteFile(invalidFile, []byte("package main\n\nfunc main( {\n"), 0644)
+		defer os.Remove(invalidFile)
+
+		result := formatGoInProcess(invalidFile, false, false, false, 1)
+		if result.ExitCode != 1 {
+			t.Errorf("expected exit code 1 for invalid Go source, got %v", result.ExitCode)
+		}
+		if !strings.Contains(result.Stderr, "invalid.go") {
+			t.Errorf("expected stderr to name the offending file, got: %s", result.Stderr)
+		}
+	})
+
+	t.Run("selectable via executeGofmtMode", func(t *testing.T) {
+		result := executeGofmtMode(testFile, true, false, false, GofmtInProcess, 10*time.Second)
+		if result.ExitCode != 0 {
+			t.Errorf("expected in-process mode to succeed, got exitCode = %v, stderr = %s", result.ExitCode, result.Stderr)
+		}
+	})
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/tools/imports"
+)
+
+// GoimportsFileError is a single file's import-fixing failure, returned
+// separately per file rather than merged into one stderr blob.
+type GoimportsFileError struct {
+	Path string
+	Err  error
+}
+
+// GoimportsFileResult is the per-file outcome of fixing one Go source
+// file's imports in-process.
+type GoimportsFileResult struct {
+	Path        string
+	NeedsFormat bool
+	Formatted   []byte
+	Diff        string
+}
+
+// GoimportsArgs are the arguments for the goimports tool.
+type GoimportsArgs struct {
+	Target      string `json:"target"`
+	List        bool   `json:"list"`
+	Diff        bool   `json:"diff"`
+	Write       bool   `json:"write"`
+	LocalPrefix string `json:"local_prefix"`
+}
+
+// executeGoimports walks target (a file or directory), adds missing
+// imports and removes unused ones via golang.org/x/tools/imports, and
+// returns a Result whose Stdout mirrors goimports' -l/-d/-w semantics
+// for list/diff/write respectively. localPrefix groups import paths
+// matching it into their own block, matching goimports' -local flag.
+// The flag surface intentionally mirrors executeGofmt.
+func executeGoimports(target string, list, diff, write bool, localPrefix string, timeout time.Duration) Result {
+	if target == "" {
+		target = "."
+	}
+
+	files, err := collectGoFiles(target)
+	if err != nil {
+		return Result{Stderr: err.Error(), ExitCode: 1}
+	}
+
+	results, fileErrors := fixGoimportsFiles(files, localPrefix)
+
+	var stdout, stderr strings.Builder
+	for _, r := range results {
+		if !r.NeedsFormat {
+			continue
+		}
+		switch {
+		case list:
+			fmt.Fprintln(&stdout, r.Path)
+		case diff:
+			stdout.WriteString(r.Diff)
+		case write:
+			if err := os.WriteFile(r.Path, r.Formatted, 0644); err != nil {
+				fileErrors = append(fileErrors, GoimportsFileError{Path: r.Path, Err: err})
+			}
+		}
+	}
+
+	for _, fe := range fileErrors {
+		fmt.Fprintf(&stderr, "%s: %v\n", fe.Path, fe.Err)
+	}
+
+	exitCode := 0
+	if len(fileErrors) > 0 {
+		exitCode = 1
+	}
+
+	return Result{Stdout: stdout.String(), Stderr: stderr.String(), ExitCode: exitCode}
+}
+
+func fixGoimportsFiles(files []string, localPrefix string) ([]GoimportsFileResult, []GoimportsFileError) {
+	type outcome struct {
+		result GoimportsFileResult
+		err    *GoimportsFileError
+	}
+
+	jobs := make(chan string)
+	outcomes := make(chan outcome)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				res, ferr := fixGoimportsFile(path, localPrefix)
+				outcomes <- outcome{result: res, err: ferr}
+			}
+		}()
+	}
+
+	go func() {
+		for _, f := range files {
+			jobs <- f
+		}
+		close(jobs)
+	}()
+
+	go func() {
+		wg.Wait()
+		close(outcomes)
+	}()
+
+	var results []GoimportsFileResult
+	var fileErrors []GoimportsFileError
+	for o := range outcomes {
+		if o.err != nil {
+			fileErrors = append(fileErrors, *o.err)
+			continue
+		}
+		results = append(results, o.result)
+	}
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Path < results[j].Path })
+	sort.Slice(fileErrors, func(i, j int) bool { return fileErrors[i].Path < fileErrors[j].Path })
+
+	return results, fileErrors
+}
+
+// importsMu serializes access to imports.LocalPrefix: the upstream
+// golang.org/x/tools/imports package exposes it as a package-level
+// var rather than an Options field, so concurrent fixGoimportsFile
+// calls (from fixGoimportsFiles' worker pool, or from multiple
+// executeGoimports invocations via shard.go) must not set it and call
+// imports.Process concurrently.
+var importsMu sync.Mutex
+
+func fixGoimportsFile(path, localPrefix string) (GoimportsFileResult, *GoimportsFileError) {
+	original, err := os.ReadFile(path)
+	if err != nil {
+		return GoimportsFileResult{}, &GoimportsFileError{Path: path, Err: err}
+	}
+
+	opts := &imports.Options{Comments: true, TabIndent: true, TabWidth: 8}
+
+	importsMu.Lock()
+	imports.LocalPrefix = localPrefix
+	formatted, err := imports.Process(path, original, opts)
+	importsMu.Unlock()
+	if err != nil {
+		return GoimportsFileResult{}, &GoimportsFileError{Path: path, Err: err}
+	}
+
+	result := GoimportsFileResult{Path: path, NeedsFormat: !bytes.Equal(original, formatted), Formatted: formatted}
+	if result.NeedsFormat {
+		result.Diff = unifiedDiff(string(original), string(formatted), path)
+	}
+	return result, nil
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGoimportsIntegration(t *testing.T) {
+	os.MkdirAll("test_data/goimports_integration", 0755)
+	defer os.RemoveAll("test_data/goimports_integration")
+
+	files := map[string]string{
+		"unused.go":  "package main\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n",
+		"missing.go": "package main\n\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n",
+	}
+
+	for filename, content := range files {
+		path := fmt.Sprintf("test_data/goimports_integration/%s", filename)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", filename, err)
+		}
+	}
+
+	t.Run("unused import is removed", func(t *testing.T) {
+		path := "test_data/goimports_integration/unused.go"
+		result := executeGoimports(path, false, false, true, "", 10*time.Second)
+		if result.ExitCode != 0 {
+			t.Fatalf("write failed: exitCode = %v, stderr = %s", result.ExitCode, result.Stderr)
+		}
+
+		formatted, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read formatted file: %v", err)
+		}
+		if strings.Contains(string(formatted), "\"os\"") {
+			t.Errorf("expected unused os import to be removed, got: %s", formatted)
+		}
+	})
+
+	t.Run("missing import is resolved", func(t *testing.T) {
+		path := "test_data/goimports_integration/missing.go"
+		result := executeGoimports(path, false, false, true, "", 10*time.Second)
+		if result.ExitCode != 0 {
+			t.Fatalf("write failed: exitCode = %v, stderr = %s", result.ExitCode, result.Stderr)
+		}
+
+		formatted, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("failed to read formatted file: %v", err)
+		}
+		if !strings.Contains(string(formatted), "\"fmt\"") {
+			t.Errorf("expected fmt import to be resolved, got: %s", formatted)
+		}
+	})
+
+	t.Run("result is idempotent", func(t *testing.T) {
+		path := "test_data/goimports_integration/unused.go"
+		result := executeGoimports(path, true, false, false, "", 10*time.Second)
+		if result.ExitCode != 0 {
+			t.Fatalf("list failed: exitCode = %v, stderr = %s", result.ExitCode, result.Stderr)
+		}
+		if strings.Contains(result.Stdout, "unused.go") {
+			t.Errorf("expected already-fixed file to not need further changes")
+		}
+	})
+}
@@ -0,0 +1,199 @@
+package main
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// callPattern matches templates of the shape "pkg.Func(:[holeName])" or
+// "Func(:[holeName])" -- the only shape the native engine understands
+// today. Anything else falls back to executeComby.
+var callPattern = regexp.MustCompile(`^([\w.]+)\(:\[(\w+)\]\)$`)
+
+// unsupportedHole matches comby's regex-hole syntax (":[name~regex]"),
+// which the native engine does not implement.
+var unsupportedHole = regexp.MustCompile(`:\[\w+~`)
+
+// GorewriteArgs are the arguments for the gorewrite tool.
+type GorewriteArgs struct {
+	MatchTemplate   string `json:"match_template"`
+	RewriteTemplate string `json:"rewrite_template"`
+	Target          string `json:"target"`
+	MatchOnly       bool   `json:"match_only"`
+	Diff            bool   `json:"diff"`
+	InPlace         bool   `json:"in_place"`
+	Ext             string `json:"ext"`
+}
+
+// executeGorewrite offers the same match/rewrite surface as executeComby
+// but, for the call-expression patterns it understands, operates on
+// go/ast directly instead of requiring the comby binary. Patterns
+// outside that subset (regex holes, multi-statement templates, etc.)
+// fall back to executeComby.
+func executeGorewrite(matchTemplate, rewriteTemplate, target string, matchOnly, diff, inPlace bool, ext string, timeout time.Duration) Result {
+	if !canHandleNatively(matchTemplate, rewriteTemplate) {
+		return executeComby(matchTemplate, rewriteTemplate, target, matchOnly, inPlace, diff, ext, "", timeout)
+	}
+
+	matchFunc, holeName := parseCallPattern(matchTemplate)
+	var rewriteFunc string
+	if rewriteTemplate != "" {
+		rewriteFunc, _ = parseCallPattern(rewriteTemplate)
+	}
+
+	files, err := collectFilesWithExt(target, orDefault(ext, ".go"))
+	if err != nil {
+		return Result{Stderr: err.Error(), ExitCode: 1}
+	}
+
+	var stdout, stderr strings.Builder
+	for _, path := range files {
+		res := rewriteFileCallExpr(path, matchFunc, holeName, rewriteFunc, matchOnly, diff, inPlace)
+		if res.err != nil {
+			stderr.WriteString(res.err.Error() + "\n")
+			continue
+		}
+		if res.output != "" {
+			stdout.WriteString(res.output)
+			stdout.WriteString("\n")
+		}
+	}
+
+	exitCode := 0
+	if stdout.Len() == 0 && stderr.Len() > 0 {
+		exitCode = 1
+	}
+	return Result{Stdout: stdout.String(), Stderr: stderr.String(), ExitCode: exitCode}
+}
+
+func canHandleNatively(matchTemplate, rewriteTemplate string) bool {
+	if unsupportedHole.MatchString(matchTemplate) || unsupportedHole.MatchString(rewriteTemplate) {
+		return false
+	}
+	if !callPattern.MatchString(matchTemplate) {
+		return false
+	}
+	if rewriteTemplate != "" && !callPattern.MatchString(rewriteTemplate) {
+		return false
+	}
+	return true
+}
+
+func parseCallPattern(tmpl string) (funcName, holeName string) {
+	m := callPattern.FindStringSubmatch(tmpl)
+	if m == nil {
+		return "", ""
+	}
+	return m[1], m[2]
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+type fileRewriteResult struct {
+	output string
+	err    error
+}
+
+// rewriteFileCallExpr matches every call expression in path whose
+// callee renders as matchFunc, capturing its argument list into
+// holeName. In matchOnly mode it returns each match rendered as source;
+// otherwise it replaces the callee with rewriteFunc (preserving the
+// captured arguments) and reprints the file via go/format.
+func rewriteFileCallExpr(path, matchFunc, holeName, rewriteFunc string, matchOnly, showDiff, inPlace bool) fileRewriteResult {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return fileRewriteResult{err: err}
+	}
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, path, src, parser.ParseComments)
+	if err != nil {
+		return fileRewriteResult{err: err}
+	}
+
+	var matches []string
+	changed := false
+
+	ast.Inspect(file, func(n ast.Node) bool {
+		call, ok := n.(*ast.CallExpr)
+		if !ok || exprString(call.Fun) != matchFunc {
+			return true
+		}
+
+		if rendered, err := renderNode(fset, call); err == nil {
+			matches = append(matches, rendered)
+		}
+
+		if !matchOnly && rewriteFunc != "" {
+			call.Fun = newSelectorExpr(rewriteFunc)
+			changed = true
+		}
+
+		return true
+	})
+
+	if matchOnly {
+		return fileRewriteResult{output: strings.Join(matches, "\n")}
+	}
+
+	if !changed {
+		return fileRewriteResult{}
+	}
+
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, file); err != nil {
+		return fileRewriteResult{err: err}
+	}
+
+	if showDiff {
+		return fileRewriteResult{output: unifiedDiff(string(src), buf.String(), path)}
+	}
+
+	if inPlace {
+		if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+			return fileRewriteResult{err: err}
+		}
+	}
+
+	return fileRewriteResult{output: buf.String()}
+}
+
+func exprString(expr ast.Expr) string {
+	switch e := expr.(type) {
+	case *ast.Ident:
+		return e.Name
+	case *ast.SelectorExpr:
+		return exprString(e.X) + "." + e.Sel.Name
+	default:
+		return ""
+	}
+}
+
+func newSelectorExpr(dotted string) ast.Expr {
+	parts := strings.Split(dotted, ".")
+	expr := ast.Expr(ast.NewIdent(parts[0]))
+	for _, p := range parts[1:] {
+		expr = &ast.SelectorExpr{X: expr, Sel: ast.NewIdent(p)}
+	}
+	return expr
+}
+
+func renderNode(fset *token.FileSet, n ast.Node) (string, error) {
+	var buf bytes.Buffer
+	if err := format.Node(&buf, fset, n); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecuteGorewrite(t *testing.T) {
+	os.MkdirAll("test_data/gorewrite", 0755)
+	defer os.RemoveAll("test_data/gorewrite")
+
+	src := "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hi\")\n\tfmt.Println(\"bye\")\n}\n"
+	testFile := "test_data/gorewrite/sample.go"
+	if err := os.WriteFile(testFile, []byte(src), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	t.Run("match only reports every call", func(t *testing.T) {
+		result := executeGorewrite("fmt.Println(:[args])", "", testFile, true, false, false, ".go", 5*time.Second)
+		if result.ExitCode != 0 {
+			t.Fatalf("match failed: exitCode = %v, stderr = %s", result.ExitCode, result.Stderr)
+		}
+		if strings.Count(result.Stdout, "fmt.Println") != 2 {
+			t.Errorf("expected two matches, got: %s", result.Stdout)
+		}
+	})
+
+	t.Run("rewrite diff does not modify the file", func(t *testing.T) {
+		before, _ := os.ReadFile(testFile)
+		result := executeGorewrite("fmt.Println(:[args])", "log.Println(:[args])", testFile, false, true, false, ".go", 5*time.Second)
+		if result.ExitCode != 0 {
+			t.Fatalf("diff failed: exitCode = %v, stderr = %s", result.ExitCode, result.Stderr)
+		}
+		if !strings.Contains(result.Stdout, "log.Println") {
+			t.Errorf("expected diff to mention log.Println, got: %s", result.Stdout)
+		}
+		after, _ := os.ReadFile(testFile)
+		if string(before) != string(after) {
+			t.Errorf("diff mode must not modify the file")
+		}
+	})
+
+	t.Run("rewrite in place replaces the callee", func(t *testing.T) {
+		result := executeGorewrite("fmt.Println(:[args])", "log.Println(:[args])", testFile, false, false, true, ".go", 5*time.Second)
+		if result.ExitCode != 0 {
+			t.Fatalf("rewrite failed: exitCode = %v, stderr = %s", result.ExitCode, result.Stderr)
+		}
+
+		rewritten, err := os.ReadFile(testFile)
+		if err != nil {
+			t.Fatalf("failed to read rewritten file: %v", err)
+		}
+		if strings.Contains(string(rewritten), "fmt.Println") {
+			t.Errorf("expected all fmt.Println calls to be rewritten, got: %s", rewritten)
+		}
+		if strings.Count(string(rewritten), "log.Println") != 2 {
+			t.Errorf("expected two log.Println calls, got: %s", rewritten)
+		}
+	})
+
+	t.Run("falls back to comby for regex holes", func(t *testing.T) {
+		if !canHandleNatively("fmt.Println(:[args])", "log.Println(:[args])") {
+			t.Errorf("expected plain call pattern to be handled natively")
+		}
+		if canHandleNatively("fmt.Println(:[args~.*])", "") {
+			t.Errorf("expected regex-hole pattern to fall back to comby")
+		}
+	})
+}
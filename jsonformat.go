@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// CombyMatch is one structured match/rewrite record, parsed from
+// comby's -json-lines output. Holes maps each named hole (e.g. "args")
+// to the source text it captured.
+type CombyMatch struct {
+	File      string            `json:"file"`
+	StartLine int               `json:"startLine"`
+	StartCol  int               `json:"startCol"`
+	EndLine   int               `json:"endLine"`
+	EndCol    int               `json:"endCol"`
+	Matched   string            `json:"matched"`
+	Rewritten string            `json:"rewritten,omitempty"`
+	Holes     map[string]string `json:"holes,omitempty"`
+}
+
+type combyJSONLine struct {
+	URI             string           `json:"uri"`
+	Matches         []combyJSONMatch `json:"matches"`
+	RewrittenSource string           `json:"rewritten_source"`
+}
+
+type combyJSONMatch struct {
+	Range       combyJSONRange `json:"range"`
+	Environment []combyJSONEnv `json:"environment"`
+	Matched     string         `json:"matched"`
+}
+
+type combyJSONRange struct {
+	Start combyJSONPos `json:"start"`
+	End   combyJSONPos `json:"end"`
+}
+
+type combyJSONPos struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+type combyJSONEnv struct {
+	Variable string `json:"variable"`
+	Value    string `json:"value"`
+}
+
+// executeCombyJSON runs comby with -json-lines against target and
+// returns its matches as a structured JSON array in Result.Stdout
+// instead of comby's human-formatted -stdout/-diff output. This lets a
+// caller filter/aggregate matches programmatically without scraping
+// diffs.
+func executeCombyJSON(matchTemplate, rewriteTemplate, target, ext, lang string, timeout time.Duration) Result {
+	args := []string{matchTemplate}
+	if rewriteTemplate != "" {
+		args = append(args, rewriteTemplate)
+	} else {
+		args = append(args, matchTemplate)
+	}
+	args = append(args, target)
+
+	if ext != "" {
+		args = append(args, "-matcher", ext)
+	} else if lang != "" {
+		args = append(args, "-matcher", lang)
+	}
+	args = append(args, "-json-lines")
+
+	result := runCommand(timeout, "comby", args...)
+	if result.ExitCode != 0 {
+		return result
+	}
+
+	matches := parseCombyJSONLines(result.Stdout)
+	data, err := json.MarshalIndent(matches, "", "  ")
+	if err != nil {
+		return Result{Stderr: err.Error(), ExitCode: 1}
+	}
+	return Result{Stdout: string(data), ExitCode: 0}
+}
+
+// parseCombyJSONLines decodes comby's -json-lines output (one JSON
+// object per matched file) into a flat slice of CombyMatch records.
+// Malformed lines are skipped rather than aborting the whole parse.
+func parseCombyJSONLines(raw string) []CombyMatch {
+	var matches []CombyMatch
+	for _, line := range strings.Split(strings.TrimSpace(raw), "\n") {
+		if line == "" {
+			continue
+		}
+		var parsed combyJSONLine
+		if err := json.Unmarshal([]byte(line), &parsed); err != nil {
+			continue
+		}
+		for _, m := range parsed.Matches {
+			holes := make(map[string]string, len(m.Environment))
+			for _, e := range m.Environment {
+				holes[e.Variable] = e.Value
+			}
+			matches = append(matches, CombyMatch{
+				File:      parsed.URI,
+				StartLine: m.Range.Start.Line,
+				StartCol:  m.Range.Start.Column,
+				EndLine:   m.Range.End.Line,
+				EndCol:    m.Range.End.Column,
+				Matched:   m.Matched,
+				Rewritten: parsed.RewrittenSource,
+				Holes:     holes,
+			})
+		}
+	}
+	return matches
+}
+
+// DiffHunkLine is one line of a DiffHunk, tagged with whether it was
+// unchanged, removed, or added.
+type DiffHunkLine struct {
+	Kind string `json:"kind"`
+	Text string `json:"text"`
+}
+
+// DiffHunk is a contiguous run of added/removed lines, anchored to its
+// 1-indexed starting line in the original and formatted file.
+type DiffHunk struct {
+	OldStart int            `json:"oldStart"`
+	NewStart int            `json:"newStart"`
+	Lines    []DiffHunkLine `json:"lines"`
+}
+
+// GofmtFileReport is one file's structured formatting outcome: whether
+// it needs reformatting, and the hunks that would change if it were.
+type GofmtFileReport struct {
+	File        string     `json:"file"`
+	NeedsFormat bool       `json:"needsFormat"`
+	DiffHunks   []DiffHunk `json:"diffHunks,omitempty"`
+}
+
+// executeGofmtJSON walks target, formats every .go file in-process via
+// go/format, and returns a structured JSON array of GofmtFileReport
+// instead of gofmt's -l/-d text output. It never writes files; it's a
+// read-only structured analogue of formatGoInProcess's list/diff modes.
+func executeGofmtJSON(target string, timeout time.Duration) Result {
+	if target == "" {
+		target = "."
+	}
+
+	files, err := collectGoFiles(target)
+	if err != nil {
+		return Result{Stderr: err.Error(), ExitCode: 1}
+	}
+
+	results, fileErrors := formatGoFiles(files, runtime.NumCPU())
+
+	var reports []GofmtFileReport
+	for _, r := range results {
+		report := GofmtFileReport{File: r.Path, NeedsFormat: r.NeedsFormat}
+		if r.NeedsFormat {
+			if original, err := os.ReadFile(r.Path); err == nil {
+				report.DiffHunks = computeDiffHunks(string(original), string(r.Formatted))
+			}
+		}
+		reports = append(reports, report)
+	}
+
+	var stderr strings.Builder
+	for _, fe := range fileErrors {
+		stderr.WriteString(fe.Path + ": " + fe.Err.Error() + "\n")
+	}
+
+	data, err := json.MarshalIndent(reports, "", "  ")
+	if err != nil {
+		return Result{Stderr: err.Error(), ExitCode: 1}
+	}
+
+	exitCode := 0
+	if len(fileErrors) > 0 {
+		exitCode = 1
+	}
+	return Result{Stdout: string(data), Stderr: stderr.String(), ExitCode: exitCode}
+}
+
+// computeDiffHunks groups the line-level diff between before and after
+// into contiguous DiffHunks, skipping over unchanged runs.
+func computeDiffHunks(before, after string) []DiffHunk {
+	ops := diffLines(strings.Split(before, "\n"), strings.Split(after, "\n"))
+
+	var hunks []DiffHunk
+	var current *DiffHunk
+	oldLine, newLine := 1, 1
+
+	flush := func() {
+		if current != nil {
+			hunks = append(hunks, *current)
+			current = nil
+		}
+	}
+
+	for _, op := range ops {
+		switch op.kind {
+		case diffEqual:
+			flush()
+			oldLine++
+			newLine++
+		case diffDelete:
+			if current == nil {
+				current = &DiffHunk{OldStart: oldLine, NewStart: newLine}
+			}
+			current.Lines = append(current.Lines, DiffHunkLine{Kind: "delete", Text: op.line})
+			oldLine++
+		case diffInsert:
+			if current == nil {
+				current = &DiffHunk{OldStart: oldLine, NewStart: newLine}
+			}
+			current.Lines = append(current.Lines, DiffHunkLine{Kind: "insert", Text: op.line})
+			newLine++
+		}
+	}
+	flush()
+
+	return hunks
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestParseCombyJSONLines(t *testing.T) {
+	raw := `{"uri":"sample.go","matches":[{"range":{"start":{"line":5,"column":2},"end":{"line":5,"column":20}},"matched":"fmt.Println(\"hi\")","environment":[{"variable":"args","value":"\"hi\""}]}],"rewritten_source":"package main\n"}
+`
+	matches := parseCombyJSONLines(raw)
+	if len(matches) != 1 {
+		t.Fatalf("expected 1 match, got %d", len(matches))
+	}
+
+	m := matches[0]
+	if m.File != "sample.go" || m.StartLine != 5 || m.EndCol != 20 {
+		t.Errorf("unexpected match fields: %+v", m)
+	}
+	if m.Holes["args"] != `"hi"` {
+		t.Errorf("expected args hole to capture quoted string, got %q", m.Holes["args"])
+	}
+}
+
+func TestComputeDiffHunks(t *testing.T) {
+	before := "package main\n\nfunc main(){\nfmt.Println(\"hi\")\n}\n"
+	after := "package main\n\nfunc main() {\n\tfmt.Println(\"hi\")\n}\n"
+
+	hunks := computeDiffHunks(before, after)
+	if len(hunks) == 0 {
+		t.Fatalf("expected at least one hunk for reformatted content")
+	}
+
+	var sawDelete, sawInsert bool
+	for _, h := range hunks {
+		for _, line := range h.Lines {
+			switch line.Kind {
+			case "delete":
+				sawDelete = true
+			case "insert":
+				sawInsert = true
+			}
+		}
+	}
+	if !sawDelete || !sawInsert {
+		t.Errorf("expected both delete and insert lines across hunks, got: %+v", hunks)
+	}
+}
+
+func TestExecuteGofmtJSON(t *testing.T) {
+	os.MkdirAll("test_data/gofmt_json", 0755)
+	defer os.RemoveAll("test_data/gofmt_json")
+
+	testFile := "test_data/gofmt_json/unformatted.go"
+	if err := os.WriteFile(testFile, []byte("package main\n\nfunc main(){\n}\n"), 0644); err != nil {
+		t.Fatalf("failed to create test file: %v", err)
+	}
+
+	result := executeGofmtJSON(testFile, 10*time.Second)
+	if result.ExitCode != 0 {
+		t.Fatalf("expected success, got exitCode = %v, stderr = %s", result.ExitCode, result.Stderr)
+	}
+
+	var reports []GofmtFileReport
+	if err := json.Unmarshal([]byte(result.Stdout), &reports); err != nil {
+		t.Fatalf("failed to unmarshal JSON output: %v", err)
+	}
+	if len(reports) != 1 || !reports[0].NeedsFormat {
+		t.Fatalf("expected one report flagging the file as needing format, got: %+v", reports)
+	}
+	if len(reports[0].DiffHunks) == 0 {
+		t.Errorf("expected diff hunks for the unformatted file")
+	}
+
+	unchanged, err := os.ReadFile(testFile)
+	if err != nil {
+		t.Fatalf("failed to read test file: %v", err)
+	}
+	if string(unchanged) != "package main\n\nfunc main(){\n}\n" {
+		t.Errorf("executeGofmtJSON must not modify the file on disk")
+	}
+}
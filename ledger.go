@@ -0,0 +1,335 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// opsLedgerPath and dryRunApprovalsPath are vars, not consts, so
+// hermetic tests can point them at a scratch directory: the ledger is
+// intentionally real-disk-only (it's an audit trail of mutations made
+// to the real filesystem by materializeFile, not of whatever activeFS
+// a tool call happens to be using), so it isn't routed through the FS
+// interface in fs.go -- MemMapFS doesn't model directories, and an
+// in-memory audit log that vanishes with the process defeats its
+// purpose of surviving across invocations. Tests that swap activeFS
+// for a MemMapFS must also redirect these two vars, or every run of
+// e.g. TestExecuteSedHermetic leaves real files behind in ./.agent/.
+var (
+	opsLedgerPath       = ".agent/ops.log"
+	dryRunApprovalsPath = ".agent/dryrun-approvals.log"
+)
+
+// OpRecord is a single logged mutation: enough to identify what changed,
+// verify the file hasn't drifted since, and reverse it.
+type OpRecord struct {
+	ID         string
+	Timestamp  time.Time
+	Tool       string
+	Target     string
+	BeforeHash string
+	AfterHash  string
+	Params     map[string]string
+	Before     string
+	Diff       string
+}
+
+func newOpID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+func hashBytes(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+func hashFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return hashBytes(data), nil
+}
+
+// recordOp appends a new mutation record to the on-disk ledger at
+// opsLedgerPath, creating the .agent directory if needed.
+func recordOp(tool, target, before, after string, params map[string]string) (OpRecord, error) {
+	rec := OpRecord{
+		ID:         newOpID(),
+		Timestamp:  time.Now(),
+		Tool:       tool,
+		Target:     target,
+		BeforeHash: hashBytes([]byte(before)),
+		AfterHash:  hashBytes([]byte(after)),
+		Params:     params,
+		Before:     before,
+		Diff:       unifiedDiff(before, after, target),
+	}
+
+	if err := os.MkdirAll(filepath.Dir(opsLedgerPath), 0755); err != nil {
+		return rec, err
+	}
+
+	f, err := os.OpenFile(opsLedgerPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return rec, err
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(encodeOpRecord(rec)); err != nil {
+		return rec, err
+	}
+
+	return rec, nil
+}
+
+func loadOps() ([]OpRecord, error) {
+	data, err := os.ReadFile(opsLedgerPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return parseOpRecords(string(data)), nil
+}
+
+// OpsListArgs are the arguments for the ops_list tool. Since and Until
+// are RFC3339 timestamps; empty fields are unfiltered.
+type OpsListArgs struct {
+	Path  string `json:"path"`
+	Tool  string `json:"tool"`
+	Since string `json:"since"`
+	Until string `json:"until"`
+}
+
+// OpsRevertArgs are the arguments for the ops_revert tool.
+type OpsRevertArgs struct {
+	OpID string `json:"op_id"`
+}
+
+// executeOpsList reports ledger entries matching the given filters.
+// Empty pathFilter/toolFilter or zero-value since/until are ignored.
+func executeOpsList(pathFilter, toolFilter string, since, until time.Time) Result {
+	records, err := loadOps()
+	if err != nil {
+		return Result{Stderr: err.Error(), ExitCode: 1}
+	}
+
+	var sb strings.Builder
+	for _, rec := range records {
+		if pathFilter != "" && rec.Target != pathFilter {
+			continue
+		}
+		if toolFilter != "" && rec.Tool != toolFilter {
+			continue
+		}
+		if !since.IsZero() && rec.Timestamp.Before(since) {
+			continue
+		}
+		if !until.IsZero() && rec.Timestamp.After(until) {
+			continue
+		}
+		fmt.Fprintf(&sb, "%s  %s  %s  %s\n", rec.ID, rec.Timestamp.Format(time.RFC3339), rec.Tool, rec.Target)
+	}
+
+	return Result{Stdout: sb.String(), ExitCode: 0}
+}
+
+// executeOpsRevert restores the target of opID's ledger entry to its
+// pre-operation content, but only if the file still matches the
+// recorded post-operation hash (i.e. it hasn't been touched since).
+func executeOpsRevert(opID string) Result {
+	records, err := loadOps()
+	if err != nil {
+		return Result{Stderr: err.Error(), ExitCode: 1}
+	}
+
+	var target *OpRecord
+	for i := range records {
+		if records[i].ID == opID {
+			target = &records[i]
+		}
+	}
+	if target == nil {
+		return Result{Stderr: fmt.Sprintf("No operation found with id %s", opID), ExitCode: 1}
+	}
+
+	currentHash, err := hashFile(target.Target)
+	if err != nil {
+		return Result{Stderr: fmt.Sprintf("Cannot read target file: %v", err), ExitCode: 1}
+	}
+	if currentHash != target.AfterHash {
+		return Result{Stderr: "Target file no longer matches the recorded post-operation hash; refusing to revert", ExitCode: 1}
+	}
+
+	if err := os.WriteFile(target.Target, []byte(target.Before), 0644); err != nil {
+		return Result{Stderr: err.Error(), ExitCode: 1}
+	}
+
+	return Result{Stdout: fmt.Sprintf("Reverted %s (%s on %s)", target.ID, target.Tool, target.Target), ExitCode: 0}
+}
+
+// foldValue indents every line of value by one space so it can span
+// multiple lines within a single recfile-style record.
+func foldValue(value string) string {
+	lines := strings.Split(value, "\n")
+	for i, l := range lines {
+		lines[i] = " " + l
+	}
+	return strings.Join(lines, "\n")
+}
+
+func encodeOpRecord(rec OpRecord) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "ID: %s\n", rec.ID)
+	fmt.Fprintf(&sb, "Timestamp: %s\n", rec.Timestamp.Format(time.RFC3339))
+	fmt.Fprintf(&sb, "Tool: %s\n", rec.Tool)
+	fmt.Fprintf(&sb, "Target: %s\n", rec.Target)
+	fmt.Fprintf(&sb, "BeforeHash: %s\n", rec.BeforeHash)
+	fmt.Fprintf(&sb, "AfterHash: %s\n", rec.AfterHash)
+
+	keys := make([]string, 0, len(rec.Params))
+	for k := range rec.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&sb, "Param-%s:\n%s\n", k, foldValue(rec.Params[k]))
+	}
+
+	fmt.Fprintf(&sb, "Before:\n%s\n", foldValue(rec.Before))
+	fmt.Fprintf(&sb, "Diff:\n%s\n", foldValue(rec.Diff))
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+// parseOpRecords parses the recfile-like ledger format produced by
+// encodeOpRecord: records are separated by a blank line, and a field's
+// value continues on following lines that start with a single space.
+func parseOpRecords(data string) []OpRecord {
+	var records []OpRecord
+	for _, block := range strings.Split(data, "\n\n") {
+		if strings.TrimSpace(block) == "" {
+			continue
+		}
+		records = append(records, parseOpRecord(block))
+	}
+	return records
+}
+
+func parseOpRecord(block string) OpRecord {
+	rec := OpRecord{Params: make(map[string]string)}
+
+	var currentKey string
+	var currentValue []string
+
+	flush := func() {
+		if currentKey == "" {
+			return
+		}
+		value := strings.TrimPrefix(strings.Join(currentValue, "\n"), "\n")
+		switch {
+		case currentKey == "ID":
+			rec.ID = value
+		case currentKey == "Timestamp":
+			rec.Timestamp, _ = time.Parse(time.RFC3339, value)
+		case currentKey == "Tool":
+			rec.Tool = value
+		case currentKey == "Target":
+			rec.Target = value
+		case currentKey == "BeforeHash":
+			rec.BeforeHash = value
+		case currentKey == "AfterHash":
+			rec.AfterHash = value
+		case currentKey == "Before":
+			rec.Before = value
+		case currentKey == "Diff":
+			rec.Diff = value
+		case strings.HasPrefix(currentKey, "Param-"):
+			rec.Params[strings.TrimPrefix(currentKey, "Param-")] = value
+		}
+	}
+
+	for _, line := range strings.Split(block, "\n") {
+		if strings.HasPrefix(line, " ") {
+			currentValue = append(currentValue, strings.TrimPrefix(line, " "))
+			continue
+		}
+		flush()
+		idx := strings.Index(line, ":")
+		if idx < 0 {
+			currentKey = ""
+			continue
+		}
+		currentKey = line[:idx]
+		rest := strings.TrimPrefix(line[idx+1:], " ")
+		if rest == "" {
+			currentValue = nil
+		} else {
+			currentValue = []string{rest}
+		}
+	}
+	flush()
+
+	return rec
+}
+
+// approveDryRun persists key as an approved dry-run so a later apply can
+// be authorized even across process restarts.
+func approveDryRun(key string) error {
+	if err := os.MkdirAll(filepath.Dir(dryRunApprovalsPath), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(dryRunApprovalsPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(key + "\n")
+	return err
+}
+
+func isDryRunApproved(key string) bool {
+	data, err := os.ReadFile(dryRunApprovalsPath)
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == key {
+			return true
+		}
+	}
+	return false
+}
+
+// clearDryRunApproval removes key from the approvals store once it has
+// been consumed by a successful apply.
+func clearDryRunApproval(key string) {
+	data, err := os.ReadFile(dryRunApprovalsPath)
+	if err != nil {
+		return
+	}
+
+	var kept []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line != "" && line != key {
+			kept = append(kept, line)
+		}
+	}
+	os.WriteFile(dryRunApprovalsPath, []byte(strings.Join(kept, "\n")+"\n"), 0644)
+}
+
+func resetDryRunApprovals() {
+	os.Remove(dryRunApprovalsPath)
+}
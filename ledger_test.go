@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func resetLedger() {
+	resetDryRunApprovals()
+	os.RemoveAll(".agent")
+}
+
+func TestOpsRevert(t *testing.T) {
+	resetLedger()
+	defer resetLedger()
+
+	testFile := "test_data/ledger_revert.txt"
+	os.WriteFile(testFile, []byte("Hello World"), 0644)
+	defer os.Remove(testFile)
+
+	t.Run("revert after apply restores byte-identical content", func(t *testing.T) {
+		resetSedCache()
+
+		if res := executeSed(testFile, "World", "Ledger", true, 10*time.Second); res.ExitCode != 0 {
+			t.Fatalf("dry-run failed: %v", res.Stderr)
+		}
+		if res := executeSed(testFile, "World", "Ledger", false, 10*time.Second); res.ExitCode != 0 {
+			t.Fatalf("apply failed: %v", res.Stderr)
+		}
+
+		records, err := loadOps()
+		if err != nil || len(records) == 0 {
+			t.Fatalf("expected at least one ledger record, got %v, err %v", records, err)
+		}
+		last := records[len(records)-1]
+
+		revertResult := executeOpsRevert(last.ID)
+		if revertResult.ExitCode != 0 {
+			t.Fatalf("revert failed: %v", revertResult.Stderr)
+		}
+
+		content, err := os.ReadFile(testFile)
+		if err != nil {
+			t.Fatalf("failed to read reverted file: %v", err)
+		}
+		if string(content) != "Hello World" {
+			t.Errorf("expected file reverted to original content, got: %q", string(content))
+		}
+	})
+
+	t.Run("revert refuses when after hash no longer matches", func(t *testing.T) {
+		resetSedCache()
+
+		os.WriteFile(testFile, []byte("Hello World"), 0644)
+		if res := executeSed(testFile, "World", "Ledger", true, 10*time.Second); res.ExitCode != 0 {
+			t.Fatalf("dry-run failed: %v", res.Stderr)
+		}
+		if res := executeSed(testFile, "World", "Ledger", false, 10*time.Second); res.ExitCode != 0 {
+			t.Fatalf("apply failed: %v", res.Stderr)
+		}
+
+		records, err := loadOps()
+		if err != nil || len(records) == 0 {
+			t.Fatalf("expected at least one ledger record, got %v, err %v", records, err)
+		}
+		last := records[len(records)-1]
+
+		// Mutate the file out-of-band so its hash no longer matches AfterHash.
+		os.WriteFile(testFile, []byte("Hello Ledger (edited)"), 0644)
+
+		revertResult := executeOpsRevert(last.ID)
+		if revertResult.ExitCode != 1 {
+			t.Errorf("expected revert to refuse, got exitCode = %v", revertResult.ExitCode)
+		}
+	})
+
+	t.Run("ledger survives simulated process restart", func(t *testing.T) {
+		resetLedger()
+
+		os.WriteFile(testFile, []byte("Hello World"), 0644)
+		executeSed(testFile, "World", "Ledger", true, 10*time.Second)
+		executeSed(testFile, "World", "Ledger", false, 10*time.Second)
+
+		// Simulate a fresh process by reloading straight from disk.
+		records, err := loadOps()
+		if err != nil {
+			t.Fatalf("failed to reload ledger: %v", err)
+		}
+		if len(records) != 1 {
+			t.Fatalf("expected 1 ledger record after reload, got %d", len(records))
+		}
+		if records[0].Tool != "sed" || records[0].Target != testFile {
+			t.Errorf("unexpected record after reload: %+v", records[0])
+		}
+
+		listResult := executeOpsList(testFile, "", time.Time{}, time.Time{})
+		if !strings.Contains(listResult.Stdout, records[0].ID) {
+			t.Errorf("expected ops list to include record id, got: %s", listResult.Stdout)
+		}
+	})
+}
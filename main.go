@@ -2,18 +2,15 @@ package main
 
 import (
 	"context"
-	"encoding/json"
+	"flag"
 	"fmt"
 	"log"
 	"math"
-	"os"
 	"os/exec"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/huh"
-	"github.com/openai/openai-go"
-	"github.com/openai/openai-go/option"
 )
 
 type BashArgs struct {
@@ -21,36 +18,102 @@ type BashArgs struct {
 }
 
 type AgentState struct {
-	client           *openai.Client
+	provider         Provider
+	agent            Agent
 	allowedCommands  map[string]bool
-	conversationMsgs []openai.ChatCompletionMessageParamUnion
+	commandAllowlist map[string]bool
+	conversationMsgs []Message
+
+	store          *ConversationStore
+	conversationID int64
+	persistedUpTo  int
+	titleGenerated bool
 }
 
 func main() {
-	apiKey := os.Getenv("OPENAI_API_KEY")
-	if apiKey == "" {
-		log.Fatal("OPENAI_API_KEY environment variable is required")
+	agentName := flag.String("agent", "shell", "named agent bundle to run (shell, readonly, coder, or a name from ~/.config/agent/agents.yaml)")
+	flag.Parse()
+
+	storePath, err := defaultStorePath()
+	if err != nil {
+		log.Fatal(err)
+	}
+	store, err := openConversationStore(storePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close()
+
+	switch flag.Arg(0) {
+	case "list":
+		if err := runList(store); err != nil {
+			log.Fatal(err)
+		}
+		return
+	case "resume":
+		runREPL(*agentName, store, parseConversationArg(flag.Arg(1)), false)
+		return
+	case "fork":
+		runREPL(*agentName, store, parseConversationArg(flag.Arg(1)), true)
+		return
+	}
+
+	runREPL(*agentName, store, 0, false)
+}
+
+// runREPL drives the huh instruction loop. When fromID is non-zero, the
+// conversation's prior messages are loaded from store first: resuming
+// continues writing to that same conversation, forking copies its
+// history into a new one (so the original is left untouched) before
+// continuing.
+func runREPL(agentName string, store *ConversationStore, fromID int64, fork bool) {
+	provider, err := NewProviderFromEnv()
+	if err != nil {
+		log.Fatal(err)
 	}
 
-	baseURL := os.Getenv("OPENAI_BASE_URL")
-
-	var client *openai.Client
-	if baseURL != "" {
-		c := openai.NewClient(
-			option.WithAPIKey(apiKey),
-			option.WithBaseURL(baseURL),
-		)
-		client = &c
-	} else {
-		c := openai.NewClient(
-			option.WithAPIKey(apiKey),
-		)
-		client = &c
+	agents, err := loadAgents()
+	if err != nil {
+		log.Fatal(err)
+	}
+	selected, ok := agents[agentName]
+	if !ok {
+		log.Fatalf("unknown agent %q", agentName)
 	}
 
 	agent := &AgentState{
-		client:          client,
+		provider:        provider,
+		agent:           selected,
 		allowedCommands: make(map[string]bool),
+		store:           store,
+	}
+	if selected.Name == "readonly" {
+		agent.commandAllowlist = readonlyAllowedCommands
+	}
+
+	var history []Message
+	if fromID != 0 {
+		loaded, err := store.loadMessages(fromID)
+		if err != nil {
+			log.Fatalf("loading conversation %d: %v", fromID, err)
+		}
+		history = loaded
+		agent.titleGenerated = true // already titled when it was first created
+
+		if fork {
+			newID, err := store.startConversation(selected.Name, &fromID)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if err := store.appendMessages(newID, history, 0, Usage{}); err != nil {
+				log.Fatal(err)
+			}
+			agent.conversationID = newID
+			fmt.Printf("Forked conversation %d into %d\n", fromID, newID)
+		} else {
+			agent.conversationID = fromID
+		}
+		agent.persistedUpTo = len(history)
 	}
 
 	for {
@@ -82,105 +145,140 @@ func main() {
 			continue
 		}
 
-		agent.conversationMsgs = []openai.ChatCompletionMessageParamUnion{
-			openai.SystemMessage("You are a helpful assistant that MUST use tools to complete tasks. You have access to 'bash' tool for executing commands and 'finish' tool when the task is complete. You MUST call one of these tools in every response - never respond without using a tool."),
-			openai.UserMessage(instruction),
+		if agent.conversationID == 0 {
+			id, err := store.startConversation(selected.Name, nil)
+			if err != nil {
+				log.Printf("Error starting conversation: %v", err)
+			}
+			agent.conversationID = id
+		}
+
+		if len(history) > 0 {
+			agent.conversationMsgs = append(history, Message{Role: "user", Content: instruction})
+		} else {
+			agent.conversationMsgs = []Message{
+				{Role: "system", Content: agent.agent.SystemPrompt},
+				{Role: "user", Content: instruction},
+			}
 		}
 
 		agent.runAgentLoop()
+		history = agent.conversationMsgs
+
+		if !agent.titleGenerated {
+			if title, err := generateTitle(context.Background(), provider, agent.conversationMsgs); err != nil {
+				log.Printf("Error generating conversation title: %v", err)
+			} else if title != "" {
+				if err := store.setTitle(agent.conversationID, title); err != nil {
+					log.Printf("Error saving conversation title: %v", err)
+				}
+				agent.titleGenerated = true
+			}
+		}
 	}
 }
 
 func (a *AgentState) runAgentLoop() {
-	for {
-		params := openai.ChatCompletionNewParams{
-			Model:    "claude-3-7-sonnet",
-			Messages: a.conversationMsgs,
-			Tools: []openai.ChatCompletionToolParam{
-				{
-					Type: "function",
-					Function: openai.FunctionDefinitionParam{
-						Name:        "bash",
-						Description: openai.String("Execute a shell command using bash"),
-						Parameters: openai.FunctionParameters{
-							"type": "object",
-							"properties": map[string]interface{}{
-								"command": map[string]interface{}{
-									"type":        "string",
-									"description": "The shell command to execute",
-								},
-							},
-							"required": []string{"command"},
-						},
-					},
-				},
-				{
-					Type: "function",
-					Function: openai.FunctionDefinitionParam{
-						Name:        "finish",
-						Description: openai.String("Finish the current task and exit the agent loop"),
-						Parameters: openai.FunctionParameters{
-							"type":       "object",
-							"properties": map[string]interface{}{},
-						},
-					},
-				},
-			},
-		}
+	tools := toolSpecsFor(a.agent)
 
-		resp, err := a.callWithRetry(params)
+	for {
+		resp, err := a.callStreamWithRetry(context.Background(), tools)
 		if err != nil {
-			log.Printf("Error calling OpenAI API after retries: %v", err)
-			return
-		}
-
-		if len(resp.Choices) == 0 {
-			log.Println("No response from OpenAI")
+			if a.handleProviderError(err) {
+				continue
+			}
 			return
 		}
 
-		choice := resp.Choices[0]
-		a.conversationMsgs = append(a.conversationMsgs, choice.Message.ToParam())
+		message := resp.Message
+		a.conversationMsgs = append(a.conversationMsgs, message)
+		a.persist(resp.Usage)
 
-		if len(choice.Message.ToolCalls) == 0 {
-			fmt.Printf("Agent: %s\n", choice.Message.Content)
-			
+		if len(message.ToolCalls) == 0 {
 			// Force the agent to use tools by adding a reminder message
-			a.conversationMsgs = append(a.conversationMsgs, openai.UserMessage(
-				"You must use either the 'bash' tool to execute commands or the 'finish' tool to complete the task. Please call one of the available tools.",
-			))
+			a.conversationMsgs = append(a.conversationMsgs, Message{
+				Role:    "user",
+				Content: "You must use either the 'bash' tool to execute commands or the 'finish' tool to complete the task. Please call one of the available tools.",
+			})
+			a.persist(Usage{})
 			continue
 		}
 
-		for _, toolCall := range choice.Message.ToolCalls {
-			switch toolCall.Function.Name {
-			case "bash":
-				var args BashArgs
-				if err := json.Unmarshal([]byte(toolCall.Function.Arguments), &args); err != nil {
-					log.Printf("Error parsing bash arguments: %v", err)
-					continue
-				}
-
-				result := a.handleBashCommand(args.Command)
-				a.conversationMsgs = append(a.conversationMsgs, openai.ToolMessage(result, toolCall.ID))
+		for _, toolCall := range message.ToolCalls {
+			entry, ok := globalToolbox[toolCall.Name]
+			if !ok {
+				log.Printf("Unknown tool: %s", toolCall.Name)
+				continue
+			}
 
-			case "finish":
-				fmt.Println("Agent finished the task.")
+			result, done := entry.Handler(a, toolCall.Arguments)
+			if done {
 				return
-
-			default:
-				log.Printf("Unknown tool: %s", toolCall.Function.Name)
 			}
+
+			a.conversationMsgs = append(a.conversationMsgs, Message{Role: "tool", Content: result, ToolCallID: toolCall.ID})
+			a.persist(Usage{})
 		}
 	}
 }
 
+// persist appends every message added to conversationMsgs since the
+// last call to the store, recording usage against the conversation.
+// It logs rather than fails the turn, since a store outage shouldn't
+// block the agent loop.
+func (a *AgentState) persist(usage Usage) {
+	if a.store == nil || a.conversationID == 0 {
+		return
+	}
+	if err := a.store.appendMessages(a.conversationID, a.conversationMsgs, a.persistedUpTo, usage); err != nil {
+		log.Printf("Error persisting conversation %d: %v", a.conversationID, err)
+		return
+	}
+	a.persistedUpTo = len(a.conversationMsgs)
+}
+
+// compactConversation relieves a ContextLengthError by summarizing the
+// oldest half of the conversation (everything after the system prompt,
+// if any) into a single system note, replacing those messages in
+// place so the next attempt fits in the model's context window.
+func (a *AgentState) compactConversation() {
+	sysIdx := 0
+	if len(a.conversationMsgs) > 0 && a.conversationMsgs[0].Role == "system" {
+		sysIdx = 1
+	}
+
+	n := (len(a.conversationMsgs) - sysIdx) / 2
+	if n < 2 {
+		return
+	}
+	oldest := a.conversationMsgs[sysIdx : sysIdx+n]
+
+	summary, err := summarizeMessages(context.Background(), a.provider, oldest)
+	if err != nil {
+		log.Printf("Error summarizing conversation history for compaction: %v", err)
+		return
+	}
+
+	compacted := make([]Message, 0, len(a.conversationMsgs)-n+1)
+	compacted = append(compacted, a.conversationMsgs[:sysIdx]...)
+	compacted = append(compacted, Message{Role: "system", Content: "Summary of earlier conversation: " + summary})
+	compacted = append(compacted, a.conversationMsgs[sysIdx+n:]...)
+
+	a.conversationMsgs = compacted
+}
+
 func (a *AgentState) handleBashCommand(command string) string {
 	baseCommand := strings.Fields(command)[0]
 
+	if a.commandAllowlist != nil {
+		if err := validateAllowlistedCommand(command, a.commandAllowlist); err != nil {
+			return fmt.Sprintf("Permission denied: %v (agent %q)", err, a.agent.Name)
+		}
+	}
+
 	if !a.allowedCommands[baseCommand] {
 		fmt.Printf("Agent wants to execute: %s\n", command)
-		
+
 		var choice string
 		err := huh.NewSelect[string]().
 			Title("Allow this command?").
@@ -192,11 +290,11 @@ func (a *AgentState) handleBashCommand(command string) string {
 			).
 			Value(&choice).
 			Run()
-		
+
 		if err != nil {
 			return "Permission denied - selection error"
 		}
-		
+
 		switch choice {
 		case "yes":
 			// Allow this one time
@@ -212,12 +310,12 @@ func (a *AgentState) handleBashCommand(command string) string {
 				Placeholder("What should the agent do instead?").
 				Value(&instruction).
 				Run()
-			
+
 			if err != nil || strings.TrimSpace(instruction) == "" {
 				return "Permission denied - no alternative instructions provided"
 			}
-			
-			a.conversationMsgs = append(a.conversationMsgs, openai.UserMessage(instruction))
+
+			a.conversationMsgs = append(a.conversationMsgs, Message{Role: "user", Content: instruction})
 			return "User provided alternative instructions"
 		default:
 			return "Permission denied - invalid response"
@@ -233,35 +331,113 @@ func (a *AgentState) handleBashCommand(command string) string {
 	return string(output)
 }
 
-func (a *AgentState) callWithRetry(params openai.ChatCompletionNewParams) (*openai.ChatCompletion, error) {
+// callStreamWithRetry drives a.provider.ChatStream with a live "typing"
+// view against a.conversationMsgs, retrying according to
+// classifyRetry's verdict on the failure: a rate limit waits out its
+// Retry-After, a context-length overflow compacts history first, and
+// an auth error gives up immediately rather than burning the budget.
+func (a *AgentState) callStreamWithRetry(ctx context.Context, tools []ToolSpec) (Response, error) {
 	maxRetries := 10
 	baseDelay := time.Second
 
 	for attempt := 0; attempt <= maxRetries; attempt++ {
-		resp, err := a.client.Chat.Completions.New(context.Background(), params)
-
+		resp, err := runStreaming(ctx, a.provider, a.conversationMsgs, tools)
 		if err == nil {
 			return resp, nil
 		}
 
-		// Check if it's a 500 error that we should retry
-		if shouldRetry(err) && attempt < maxRetries {
-			delay := time.Duration(float64(baseDelay) * math.Pow(2, float64(attempt)))
-			log.Printf("API call failed (attempt %d/%d): %v. Retrying in %v...", attempt+1, maxRetries+1, err, delay)
-			time.Sleep(delay)
+		decision := classifyRetry(err)
+		if decision.compact {
+			a.compactConversation()
+			log.Printf("Context length exceeded: compacted oldest messages and retrying")
+			continue
+		}
+
+		if decision.retry && attempt < maxRetries {
+			wait := decision.wait
+			if wait == 0 {
+				wait = time.Duration(float64(baseDelay) * math.Pow(2, float64(attempt)))
+			}
+			log.Printf("Provider call failed (attempt %d/%d): %v. Retrying in %v...", attempt+1, maxRetries+1, err, wait)
+			time.Sleep(wait)
 			continue
 		}
 
-		return nil, err
+		return Response{}, err
 	}
 
-	return nil, fmt.Errorf("exceeded maximum retries")
+	return Response{}, fmt.Errorf("exceeded maximum retries")
 }
 
-func shouldRetry(err error) bool {
-	// Check if it's an HTTP 500 error
-	if httpErr, ok := err.(*openai.Error); ok {
-		return httpErr.StatusCode >= 500 && httpErr.StatusCode < 600
+// ErrorEvent is a MessageKindError-style notification for a failed
+// provider call (after callStreamWithRetry gave up), carrying enough
+// to render inline in the huh UI instead of just logging and killing
+// the loop. Fatal is set for an AuthError, which no retry can fix.
+type ErrorEvent struct {
+	Err   error
+	Fatal bool
+}
+
+func newErrorEvent(err error) ErrorEvent {
+	_, fatal := err.(*AuthError)
+	return ErrorEvent{Err: err, Fatal: fatal}
+}
+
+// handleProviderError renders a failed provider call inline and, if
+// it's recoverable, lets the user choose how to proceed. It returns
+// true when the agent loop should retry the call, false when it
+// should abort the current task.
+func (a *AgentState) handleProviderError(err error) bool {
+	ev := newErrorEvent(err)
+	fmt.Printf("Error: %v\n", ev.Err)
+
+	if ev.Fatal {
+		fmt.Println("This error can't be retried; aborting the task.")
+		return false
+	}
+
+	var choice string
+	selectErr := huh.NewSelect[string]().
+		Title("The provider call failed. How do you want to proceed?").
+		Options(
+			huh.NewOption("Retry", "retry"),
+			huh.NewOption("Edit last message and retry", "edit"),
+			huh.NewOption("Abort", "abort"),
+		).
+		Value(&choice).
+		Run()
+	if selectErr != nil {
+		return false
 	}
-	return false
+
+	switch choice {
+	case "retry":
+		return true
+	case "edit":
+		return a.editLastMessage()
+	default:
+		return false
+	}
+}
+
+// editLastMessage lets the user rewrite the most recent message in the
+// conversation before callStreamWithRetry is retried.
+func (a *AgentState) editLastMessage() bool {
+	if len(a.conversationMsgs) == 0 {
+		return false
+	}
+
+	last := len(a.conversationMsgs) - 1
+	content := a.conversationMsgs[last].Content
+
+	err := huh.NewText().
+		Title("Edit the last message").
+		Value(&content).
+		Run()
+	if err != nil {
+		return false
+	}
+
+	a.conversationMsgs[last].Content = content
+	return true
 }
@@ -8,9 +8,9 @@ import (
 	"time"
 )
 
-// Test helper to reset sed cache between tests
+// Test helper to reset the persisted dry-run approval store between tests
 func resetSedCache() {
-	sedDryRunCache = make(map[string]bool)
+	resetDryRunApprovals()
 }
 
 func TestExecuteShellCommand(t *testing.T) {
@@ -321,11 +321,13 @@ func TestGenerateSedOperationKey(t *testing.T) {
 		filePath       string
 		searchPattern  string
 		replacePattern string
+		contentHash    string
 		wantSameKey    bool
 		compareWith    struct {
 			filePath       string
 			searchPattern  string
 			replacePattern string
+			contentHash    string
 		}
 	}{
 		{
@@ -333,15 +335,18 @@ func TestGenerateSedOperationKey(t *testing.T) {
 			filePath:       "test.txt",
 			searchPattern:  "hello",
 			replacePattern: "world",
+			contentHash:    "abc123",
 			wantSameKey:    true,
 			compareWith: struct {
 				filePath       string
 				searchPattern  string
 				replacePattern string
+				contentHash    string
 			}{
 				filePath:       "test.txt",
 				searchPattern:  "hello",
 				replacePattern: "world",
+				contentHash:    "abc123",
 			},
 		},
 		{
@@ -349,15 +354,18 @@ func TestGenerateSedOperationKey(t *testing.T) {
 			filePath:       "test1.txt",
 			searchPattern:  "hello",
 			replacePattern: "world",
+			contentHash:    "abc123",
 			wantSameKey:    false,
 			compareWith: struct {
 				filePath       string
 				searchPattern  string
 				replacePattern string
+				contentHash    string
 			}{
 				filePath:       "test2.txt",
 				searchPattern:  "hello",
 				replacePattern: "world",
+				contentHash:    "abc123",
 			},
 		},
 		{
@@ -365,23 +373,45 @@ func TestGenerateSedOperationKey(t *testing.T) {
 			filePath:       "test.txt",
 			searchPattern:  "hello",
 			replacePattern: "world",
+			contentHash:    "abc123",
 			wantSameKey:    false,
 			compareWith: struct {
 				filePath       string
 				searchPattern  string
 				replacePattern string
+				contentHash    string
 			}{
 				filePath:       "test.txt",
 				searchPattern:  "hi",
 				replacePattern: "world",
+				contentHash:    "abc123",
+			},
+		},
+		{
+			name:           "different content hash should have different keys",
+			filePath:       "test.txt",
+			searchPattern:  "hello",
+			replacePattern: "world",
+			contentHash:    "abc123",
+			wantSameKey:    false,
+			compareWith: struct {
+				filePath       string
+				searchPattern  string
+				replacePattern string
+				contentHash    string
+			}{
+				filePath:       "test.txt",
+				searchPattern:  "hello",
+				replacePattern: "world",
+				contentHash:    "def456",
 			},
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			key1 := generateSedOperationKey(tt.filePath, tt.searchPattern, tt.replacePattern)
-			key2 := generateSedOperationKey(tt.compareWith.filePath, tt.compareWith.searchPattern, tt.compareWith.replacePattern)
+			key1 := generateSedOperationKey(tt.filePath, tt.searchPattern, tt.replacePattern, tt.contentHash)
+			key2 := generateSedOperationKey(tt.compareWith.filePath, tt.compareWith.searchPattern, tt.compareWith.replacePattern, tt.compareWith.contentHash)
 
 			if tt.wantSameKey {
 				if key1 != key2 {
@@ -410,8 +440,9 @@ func TestSedCacheManagement(t *testing.T) {
 	t.Run("cache should be populated after dry-run", func(t *testing.T) {
 		resetSedCache()
 
-		key := generateSedOperationKey(testFile, "World", "Universe")
-		if sedDryRunCache[key] {
+		contentHash, _ := hashFile(testFile)
+		key := generateSedOperationKey(testFile, "World", "Universe", contentHash)
+		if isDryRunApproved(key) {
 			t.Errorf("Cache should be empty initially")
 		}
 
@@ -420,7 +451,7 @@ func TestSedCacheManagement(t *testing.T) {
 			t.Fatalf("Dry-run failed: %v", result.Stderr)
 		}
 
-		if !sedDryRunCache[key] {
+		if !isDryRunApproved(key) {
 			t.Errorf("Cache should be populated after successful dry-run")
 		}
 	})
@@ -428,7 +459,8 @@ func TestSedCacheManagement(t *testing.T) {
 	t.Run("cache should be cleared after successful apply", func(t *testing.T) {
 		resetSedCache()
 
-		key := generateSedOperationKey(testFile, "World", "Universe")
+		contentHash, _ := hashFile(testFile)
+		key := generateSedOperationKey(testFile, "World", "Universe", contentHash)
 
 		// Do dry-run
 		dryRunResult := executeSed(testFile, "World", "Universe", true, 10*time.Second)
@@ -436,7 +468,7 @@ func TestSedCacheManagement(t *testing.T) {
 			t.Fatalf("Dry-run failed: %v", dryRunResult.Stderr)
 		}
 
-		if !sedDryRunCache[key] {
+		if !isDryRunApproved(key) {
 			t.Fatalf("Cache should be populated after dry-run")
 		}
 
@@ -446,7 +478,7 @@ func TestSedCacheManagement(t *testing.T) {
 			t.Fatalf("Apply failed: %v", applyResult.Stderr)
 		}
 
-		if sedDryRunCache[key] {
+		if isDryRunApproved(key) {
 			t.Errorf("Cache should be cleared after successful apply")
 		}
 	})
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+)
+
+// openAIProvider talks to the OpenAI chat-completions API, or any
+// OpenAI-compatible endpoint (LocalAI, Ollama, vLLM) when constructed
+// with a custom base URL -- the wire format is identical.
+type openAIProvider struct {
+	client *openai.Client
+	model  string
+}
+
+func newOpenAIProvider(apiKey, baseURL, model string) *openAIProvider {
+	opts := []option.RequestOption{option.WithAPIKey(apiKey)}
+	if baseURL != "" {
+		opts = append(opts, option.WithBaseURL(baseURL))
+	}
+	client := openai.NewClient(opts...)
+	return &openAIProvider{client: &client, model: model}
+}
+
+func (p *openAIProvider) Chat(ctx context.Context, msgs []Message, tools []ToolSpec) (Response, error) {
+	resp, err := p.client.Chat.Completions.New(ctx, p.buildParams(msgs, tools))
+	if err != nil {
+		return Response{}, wrapOpenAIError(err)
+	}
+	if len(resp.Choices) == 0 {
+		return Response{}, fmt.Errorf("no choices returned")
+	}
+	return Response{
+		Message: fromOpenAIMessage(resp.Choices[0].Message),
+		Usage:   Usage{PromptTokens: int(resp.Usage.PromptTokens), CompletionTokens: int(resp.Usage.CompletionTokens)},
+	}, nil
+}
+
+// toolCallAccumulator assembles a single tool call's Name and Arguments
+// from the fragmented deltas the streaming API emits, keyed by the
+// tool call's index in the assistant message.
+type toolCallAccumulator struct {
+	id        string
+	name      string
+	arguments string
+}
+
+func (p *openAIProvider) ChatStream(ctx context.Context, msgs []Message, tools []ToolSpec, onDelta func(StreamDelta)) (Response, error) {
+	params := p.buildParams(msgs, tools)
+	params.StreamOptions = openai.ChatCompletionStreamOptionsParam{IncludeUsage: openai.Bool(true)}
+
+	stream := p.client.Chat.Completions.NewStreaming(ctx, params)
+	defer stream.Close()
+
+	var role string
+	var content strings.Builder
+	var usage Usage
+	toolCalls := map[int64]*toolCallAccumulator{}
+	var order []int64
+
+	for stream.Next() {
+		chunk := stream.Current()
+		if chunk.Usage.PromptTokens != 0 || chunk.Usage.CompletionTokens != 0 {
+			usage = Usage{PromptTokens: int(chunk.Usage.PromptTokens), CompletionTokens: int(chunk.Usage.CompletionTokens)}
+		}
+		if len(chunk.Choices) == 0 {
+			continue
+		}
+		delta := chunk.Choices[0].Delta
+
+		if delta.Role != "" {
+			role = delta.Role
+		}
+		if delta.Content != "" {
+			content.WriteString(delta.Content)
+			onDelta(StreamDelta(delta.Content))
+		}
+
+		for _, tc := range delta.ToolCalls {
+			acc, ok := toolCalls[tc.Index]
+			if !ok {
+				acc = &toolCallAccumulator{}
+				toolCalls[tc.Index] = acc
+				order = append(order, tc.Index)
+			}
+			if tc.ID != "" {
+				acc.id = tc.ID
+			}
+			acc.name += tc.Function.Name
+			acc.arguments += tc.Function.Arguments
+		}
+	}
+
+	if err := stream.Err(); err != nil {
+		return Response{}, wrapOpenAIError(err)
+	}
+
+	if role == "" {
+		role = "assistant"
+	}
+	message := Message{Role: role, Content: content.String()}
+	for _, idx := range order {
+		acc := toolCalls[idx]
+		message.ToolCalls = append(message.ToolCalls, ToolCall{ID: acc.id, Name: acc.name, Arguments: acc.arguments})
+	}
+
+	return Response{Message: message, Usage: usage}, nil
+}
+
+func (p *openAIProvider) buildParams(msgs []Message, tools []ToolSpec) openai.ChatCompletionNewParams {
+	params := openai.ChatCompletionNewParams{
+		Model:    p.model,
+		Messages: toOpenAIMessages(msgs),
+	}
+	for _, t := range tools {
+		params.Tools = append(params.Tools, openai.ChatCompletionToolParam{
+			Type: "function",
+			Function: openai.FunctionDefinitionParam{
+				Name:        t.Name,
+				Description: openai.String(t.Description),
+				Parameters:  openai.FunctionParameters(t.Parameters),
+			},
+		})
+	}
+	return params
+}
+
+func toOpenAIMessages(msgs []Message) []openai.ChatCompletionMessageParamUnion {
+	var out []openai.ChatCompletionMessageParamUnion
+	for _, m := range msgs {
+		switch m.Role {
+		case "system":
+			out = append(out, openai.SystemMessage(m.Content))
+		case "user":
+			out = append(out, openai.UserMessage(m.Content))
+		case "tool":
+			out = append(out, openai.ToolMessage(m.Content, m.ToolCallID))
+		case "assistant":
+			var toolCalls []openai.ChatCompletionMessageToolCall
+			for _, tc := range m.ToolCalls {
+				toolCalls = append(toolCalls, openai.ChatCompletionMessageToolCall{
+					ID:   tc.ID,
+					Type: "function",
+					Function: openai.ChatCompletionMessageToolCallFunction{
+						Name:      tc.Name,
+						Arguments: tc.Arguments,
+					},
+				})
+			}
+			assistantMsg := openai.ChatCompletionMessage{Role: "assistant", Content: m.Content, ToolCalls: toolCalls}
+			out = append(out, assistantMsg.ToParam())
+		}
+	}
+	return out
+}
+
+func fromOpenAIMessage(msg openai.ChatCompletionMessage) Message {
+	m := Message{Role: "assistant", Content: msg.Content}
+	for _, tc := range msg.ToolCalls {
+		m.ToolCalls = append(m.ToolCalls, ToolCall{ID: tc.ID, Name: tc.Function.Name, Arguments: tc.Function.Arguments})
+	}
+	return m
+}
+
+// wrapOpenAIError normalizes an OpenAI SDK error into the typed error
+// taxonomy classifyRetry dispatches on, so callers don't need to know
+// which backend produced the failure.
+func wrapOpenAIError(err error) error {
+	httpErr, ok := err.(*openai.Error)
+	if !ok {
+		return &NetworkError{Err: err}
+	}
+	var header http.Header
+	if httpErr.Response != nil {
+		header = httpErr.Response.Header
+	}
+	return classifyHTTPError(httpErr.StatusCode, httpErr.Error(), header)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// Message is the provider-agnostic representation of one turn in the
+// conversation. Only the fields relevant to a given role are set:
+// assistant messages may carry ToolCalls, tool-result messages set
+// ToolCallID to the call they're responding to.
+type Message struct {
+	Role       string
+	Content    string
+	ToolCalls  []ToolCall
+	ToolCallID string
+}
+
+// ToolCall is one assembled function call the model asked to invoke.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments string
+}
+
+// ToolSpec describes a tool available to the model, independent of how
+// a given provider encodes function-calling in its wire format.
+type ToolSpec struct {
+	Name        string
+	Description string
+	Parameters  map[string]interface{}
+}
+
+// Usage records a turn's token accounting, so callers can track
+// cumulative cost per conversation.
+type Usage struct {
+	PromptTokens     int
+	CompletionTokens int
+}
+
+// Response is a completed (or fully reassembled, if streamed) model
+// turn.
+type Response struct {
+	Message Message
+	Usage   Usage
+}
+
+// StreamDelta is emitted for each chunk of assistant content received
+// while streaming, so callers can render a live "typing" view.
+type StreamDelta string
+
+// Provider abstracts a chat-completion backend so AgentState and
+// handleBashCommand never need to know whether they're talking to
+// OpenAI, Anthropic, or an OpenAI-compatible local server.
+type Provider interface {
+	Chat(ctx context.Context, msgs []Message, tools []ToolSpec) (Response, error)
+	ChatStream(ctx context.Context, msgs []Message, tools []ToolSpec, onDelta func(StreamDelta)) (Response, error)
+}
+
+// ProviderError is the catch-all wire error for an HTTP failure that
+// classifyHTTPError didn't recognize as one of the more specific
+// RateLimitError/ServerError/ContextLengthError/AuthError cases.
+type ProviderError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("provider error (status %d): %s", e.StatusCode, e.Body)
+}
+
+// NewProviderFromEnv selects and constructs a Provider based on
+// AGENT_PROVIDER ("openai", "anthropic", or "local"; defaults to
+// "openai"), reading the matching *_API_KEY, *_MODEL and *_BASE_URL
+// environment variables.
+func NewProviderFromEnv() (Provider, error) {
+	name := os.Getenv("AGENT_PROVIDER")
+	if name == "" {
+		name = "openai"
+	}
+
+	switch name {
+	case "openai":
+		apiKey := os.Getenv("OPENAI_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("OPENAI_API_KEY environment variable is required")
+		}
+		model := os.Getenv("OPENAI_MODEL")
+		if model == "" {
+			model = "gpt-4o"
+		}
+		return newOpenAIProvider(apiKey, os.Getenv("OPENAI_BASE_URL"), model), nil
+
+	case "anthropic":
+		apiKey := os.Getenv("ANTHROPIC_API_KEY")
+		if apiKey == "" {
+			return nil, fmt.Errorf("ANTHROPIC_API_KEY environment variable is required")
+		}
+		model := os.Getenv("ANTHROPIC_MODEL")
+		if model == "" {
+			model = "claude-3-7-sonnet-20250219"
+		}
+		baseURL := os.Getenv("ANTHROPIC_BASE_URL")
+		if baseURL == "" {
+			baseURL = "https://api.anthropic.com"
+		}
+		return newAnthropicProvider(apiKey, baseURL, model), nil
+
+	case "local":
+		model := os.Getenv("LOCAL_MODEL")
+		if model == "" {
+			return nil, fmt.Errorf("LOCAL_MODEL environment variable is required")
+		}
+		baseURL := os.Getenv("LOCAL_BASE_URL")
+		if baseURL == "" {
+			return nil, fmt.Errorf("LOCAL_BASE_URL environment variable is required")
+		}
+		return newOpenAIProvider(os.Getenv("LOCAL_API_KEY"), baseURL, model), nil
+
+	default:
+		return nil, fmt.Errorf("unknown AGENT_PROVIDER %q (want openai, anthropic, or local)", name)
+	}
+}
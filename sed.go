@@ -0,0 +1,81 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// SedArgs are the arguments for the sed tool. Applying a change
+// requires a prior DryRun call with identical Path/Search/Replace.
+type SedArgs struct {
+	Path    string `json:"path"`
+	Search  string `json:"search"`
+	Replace string `json:"replace"`
+	DryRun  bool   `json:"dry_run"`
+}
+
+// generateSedOperationKey derives the dry-run approval key for a sed
+// invocation. contentHash ties the key to the target's current content
+// so an out-of-band edit invalidates a previously approved dry-run.
+func generateSedOperationKey(filePath, searchPattern, replacePattern, contentHash string) string {
+	h := sha256.Sum256([]byte(filePath + "\x00" + searchPattern + "\x00" + replacePattern + "\x00" + contentHash))
+	return hex.EncodeToString(h[:])
+}
+
+func executeSed(filePath, searchPattern, replacePattern string, dryRun bool, timeout time.Duration) Result {
+	before, err := activeFS.ReadFile(filePath)
+	if err != nil {
+		return Result{Stderr: fmt.Sprintf("File does not exist: %s", filePath), ExitCode: 1}
+	}
+
+	key := generateSedOperationKey(filePath, searchPattern, replacePattern, hashBytes(before))
+	expr := fmt.Sprintf("s/%s/%s/g", searchPattern, replacePattern)
+
+	if dryRun {
+		realPath, cleanup, err := materializeFile(filePath, false)
+		if err != nil {
+			return Result{Stderr: err.Error(), ExitCode: 1}
+		}
+		defer cleanup()
+
+		result := runCommand(timeout, "sed", expr, realPath)
+		if result.ExitCode == 0 {
+			if err := approveDryRun(key); err != nil {
+				return Result{Stderr: fmt.Sprintf("Dry-run succeeded but approval could not be recorded: %v", err), ExitCode: 1}
+			}
+		}
+		return result
+	}
+
+	if !isDryRunApproved(key) {
+		return Result{Stderr: "Must perform dry-run with identical parameters before applying changes", ExitCode: 1}
+	}
+
+	realPath, cleanup, err := materializeFile(filePath, true)
+	if err != nil {
+		return Result{Stderr: err.Error(), ExitCode: 1}
+	}
+
+	result := runCommand(timeout, "sed", "-i", expr, realPath)
+	cleanup()
+	if result.ExitCode != 0 {
+		return result
+	}
+
+	after, err := activeFS.ReadFile(filePath)
+	if err != nil {
+		return Result{Stderr: err.Error(), ExitCode: 1}
+	}
+
+	if _, err := recordOp("sed", filePath, string(before), string(after), map[string]string{
+		"search":  searchPattern,
+		"replace": replacePattern,
+	}); err != nil {
+		return Result{Stderr: fmt.Sprintf("Applied but failed to record ledger entry: %v", err), ExitCode: 1}
+	}
+
+	clearDryRunApproval(key)
+	return result
+}
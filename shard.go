@@ -0,0 +1,140 @@
+package main
+
+import (
+	"hash/fnv"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RunOptions configures a sharded, parallel run of comby or gofmt over a
+// tree of files.
+type RunOptions struct {
+	Parallelism int
+	Shard       int
+	Shards      int
+}
+
+// AggregatedResult merges the individual Results of a sharded run:
+// Combined concatenates every file's stdout/stderr (in file order) and
+// reflects the last non-zero exit code seen, while PerFile preserves
+// each file's own Result for callers that want fine-grained detail.
+type AggregatedResult struct {
+	PerFile  map[string]Result
+	Combined Result
+}
+
+// hashShard mirrors Go's test runner's stable sharding: path is assigned
+// to shard hash(path) % shards via FNV-1a.
+func hashShard(path string, shards int) int {
+	h := fnv.New32a()
+	h.Write([]byte(path))
+	return int(h.Sum32() % uint32(shards))
+}
+
+func selectFilesForShard(files []string, shard, shards int) []string {
+	if shards <= 1 {
+		return files
+	}
+	var selected []string
+	for _, f := range files {
+		if hashShard(f, shards) == shard {
+			selected = append(selected, f)
+		}
+	}
+	return selected
+}
+
+// runSharded applies run to every file in files that falls in opts'
+// shard, using a worker pool bounded by opts.Parallelism.
+func runSharded(files []string, opts RunOptions, run func(path string) Result) AggregatedResult {
+	parallelism := opts.Parallelism
+	if parallelism <= 0 {
+		parallelism = runtime.NumCPU()
+	}
+
+	selected := selectFilesForShard(files, opts.Shard, opts.Shards)
+
+	perFile := make(map[string]Result, len(selected))
+	var mu sync.Mutex
+
+	sem := make(chan struct{}, parallelism)
+	var wg sync.WaitGroup
+	for _, f := range selected {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func(path string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			res := run(path)
+
+			mu.Lock()
+			perFile[path] = res
+			mu.Unlock()
+		}(f)
+	}
+	wg.Wait()
+
+	return AggregatedResult{PerFile: perFile, Combined: combineResults(perFile, selected)}
+}
+
+func combineResults(perFile map[string]Result, order []string) Result {
+	var stdout, stderr strings.Builder
+	exitCode := 0
+	for _, path := range order {
+		res := perFile[path]
+		stdout.WriteString(res.Stdout)
+		stderr.WriteString(res.Stderr)
+		if res.ExitCode != 0 {
+			exitCode = res.ExitCode
+		}
+	}
+	return Result{Stdout: stdout.String(), Stderr: stderr.String(), ExitCode: exitCode}
+}
+
+func collectFilesWithExt(root, ext string) ([]string, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{root}, nil
+	}
+
+	var files []string
+	err = filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && (ext == "" || strings.HasSuffix(path, ext)) {
+			files = append(files, path)
+		}
+		return nil
+	})
+	return files, err
+}
+
+// executeCombyTree runs executeComby independently over every file with
+// the given extension under root, sharded and parallelized per opts.
+func executeCombyTree(matchTemplate, rewriteTemplate, root, ext, lang string, diff bool, timeout time.Duration, opts RunOptions) AggregatedResult {
+	files, _ := collectFilesWithExt(root, ext)
+	matchOnly := rewriteTemplate == ""
+
+	return runSharded(files, opts, func(path string) Result {
+		return executeComby(matchTemplate, rewriteTemplate, path, matchOnly, !matchOnly, diff, ext, lang, timeout)
+	})
+}
+
+// executeGofmtTree runs executeGofmt independently over every .go file
+// under root, sharded and parallelized per opts.
+func executeGofmtTree(root string, list, diff, write bool, timeout time.Duration, opts RunOptions) AggregatedResult {
+	files, _ := collectGoFiles(root)
+
+	return runSharded(files, opts, func(path string) Result {
+		return executeGofmt(path, list, diff, write, timeout)
+	})
+}
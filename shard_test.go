@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecuteGofmtTree(t *testing.T) {
+	os.MkdirAll("test_data/shard_gofmt", 0755)
+	defer os.RemoveAll("test_data/shard_gofmt")
+
+	files := map[string]string{
+		"a.go": "package main\n\nfunc A(){}\n",
+		"b.go": "package main\n\nfunc B( ) {\n}\n",
+	}
+	for name, content := range files {
+		os.WriteFile("test_data/shard_gofmt/"+name, []byte(content), 0644)
+	}
+
+	agg := executeGofmtTree("test_data/shard_gofmt", true, false, false, 10*time.Second, RunOptions{Parallelism: 2})
+
+	if len(agg.PerFile) != 2 {
+		t.Fatalf("expected per-file results for both files, got %d", len(agg.PerFile))
+	}
+	if !strings.Contains(agg.Combined.Stdout, "b.go") {
+		t.Errorf("expected combined output to flag b.go as needing formatting, got: %s", agg.Combined.Stdout)
+	}
+}
+
+func TestSelectFilesForShard(t *testing.T) {
+	files := []string{"one.go", "two.go", "three.go", "four.go", "five.go"}
+
+	var total int
+	for shard := 0; shard < 3; shard++ {
+		total += len(selectFilesForShard(files, shard, 3))
+	}
+
+	if total != len(files) {
+		t.Errorf("expected shards to partition all files exactly once, got %d total", total)
+	}
+
+	// Same file should always land in the same shard (stable hashing).
+	first := hashShard("one.go", 3)
+	second := hashShard("one.go", 3)
+	if first != second {
+		t.Errorf("expected stable shard assignment, got %d then %d", first, second)
+	}
+}
@@ -0,0 +1,313 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// ConversationStore persists conversations and their messages to a
+// SQLite database so a session can be listed, resumed, or forked from
+// a later invocation of the binary.
+type ConversationStore struct {
+	db *sql.DB
+}
+
+// conversationStoreSchema creates the four tables backing
+// ConversationStore: conversations, messages, tool_calls, and
+// tool_results. Tool calls and their results are split out of the
+// assistant/tool messages that carry them so usage and branching
+// queries don't need to parse message content.
+const conversationStoreSchema = `
+CREATE TABLE IF NOT EXISTS conversations (
+	id                INTEGER PRIMARY KEY AUTOINCREMENT,
+	parent_id         INTEGER REFERENCES conversations(id),
+	agent_name        TEXT NOT NULL,
+	title             TEXT NOT NULL DEFAULT '',
+	prompt_tokens     INTEGER NOT NULL DEFAULT 0,
+	completion_tokens INTEGER NOT NULL DEFAULT 0,
+	created_at        DATETIME NOT NULL,
+	updated_at        DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS messages (
+	id              INTEGER PRIMARY KEY AUTOINCREMENT,
+	conversation_id INTEGER NOT NULL REFERENCES conversations(id),
+	seq             INTEGER NOT NULL,
+	role            TEXT NOT NULL,
+	content         TEXT NOT NULL,
+	tool_call_id    TEXT NOT NULL DEFAULT '',
+	created_at      DATETIME NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS tool_calls (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	message_id   INTEGER NOT NULL REFERENCES messages(id),
+	tool_call_id TEXT NOT NULL,
+	name         TEXT NOT NULL,
+	arguments    TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS tool_results (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	message_id   INTEGER NOT NULL REFERENCES messages(id),
+	tool_call_id TEXT NOT NULL,
+	content      TEXT NOT NULL
+);
+`
+
+// defaultStorePath is ~/.config/agent/history.db, alongside agents.yaml.
+func defaultStorePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".config", "agent", "history.db"), nil
+}
+
+// openConversationStore opens (creating if necessary) the SQLite
+// database at path and ensures its schema is up to date.
+func openConversationStore(path string) (*ConversationStore, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("creating store directory: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("opening conversation store: %w", err)
+	}
+
+	if _, err := db.Exec(conversationStoreSchema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("migrating conversation store: %w", err)
+	}
+
+	return &ConversationStore{db: db}, nil
+}
+
+func (s *ConversationStore) Close() error { return s.db.Close() }
+
+// ConversationSummary is one row of `agent list` output.
+type ConversationSummary struct {
+	ID               int64
+	ParentID         sql.NullInt64
+	AgentName        string
+	Title            string
+	PromptTokens     int
+	CompletionTokens int
+	UpdatedAt        time.Time
+}
+
+// startConversation inserts a new, empty conversation row and returns
+// its ID. parentID is non-nil when this conversation was created by
+// `agent fork`.
+func (s *ConversationStore) startConversation(agentName string, parentID *int64) (int64, error) {
+	now := time.Now()
+	res, err := s.db.Exec(
+		`INSERT INTO conversations (parent_id, agent_name, created_at, updated_at) VALUES (?, ?, ?, ?)`,
+		parentID, agentName, now, now,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.LastInsertId()
+}
+
+// appendMessages persists every message in msgs[from:] to conversationID
+// in order, along with any tool calls or tool results they carry, and
+// bumps the conversation's cumulative token usage and updated_at.
+func (s *ConversationStore) appendMessages(conversationID int64, msgs []Message, from int, usage Usage) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var seq int
+	if err := tx.QueryRow(`SELECT COALESCE(MAX(seq), -1) + 1 FROM messages WHERE conversation_id = ?`, conversationID).Scan(&seq); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	for _, m := range msgs[from:] {
+		res, err := tx.Exec(
+			`INSERT INTO messages (conversation_id, seq, role, content, tool_call_id, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			conversationID, seq, m.Role, m.Content, m.ToolCallID, now,
+		)
+		if err != nil {
+			return err
+		}
+		messageID, err := res.LastInsertId()
+		if err != nil {
+			return err
+		}
+
+		for _, tc := range m.ToolCalls {
+			if _, err := tx.Exec(
+				`INSERT INTO tool_calls (message_id, tool_call_id, name, arguments) VALUES (?, ?, ?, ?)`,
+				messageID, tc.ID, tc.Name, tc.Arguments,
+			); err != nil {
+				return err
+			}
+		}
+		if m.Role == "tool" {
+			if _, err := tx.Exec(
+				`INSERT INTO tool_results (message_id, tool_call_id, content) VALUES (?, ?, ?)`,
+				messageID, m.ToolCallID, m.Content,
+			); err != nil {
+				return err
+			}
+		}
+
+		seq++
+	}
+
+	if _, err := tx.Exec(
+		`UPDATE conversations SET prompt_tokens = prompt_tokens + ?, completion_tokens = completion_tokens + ?, updated_at = ? WHERE id = ?`,
+		usage.PromptTokens, usage.CompletionTokens, now, conversationID,
+	); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// setTitle records the auto-generated title for a conversation.
+func (s *ConversationStore) setTitle(conversationID int64, title string) error {
+	_, err := s.db.Exec(`UPDATE conversations SET title = ? WHERE id = ?`, title, conversationID)
+	return err
+}
+
+// loadMessages rehydrates a conversation's full message list in seq
+// order, for `agent resume` and `agent fork`.
+func (s *ConversationStore) loadMessages(conversationID int64) ([]Message, error) {
+	rows, err := s.db.Query(`SELECT id, role, content, tool_call_id FROM messages WHERE conversation_id = ? ORDER BY seq`, conversationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var msgs []Message
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		var m Message
+		if err := rows.Scan(&id, &m.Role, &m.Content, &m.ToolCallID); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+		msgs = append(msgs, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i, id := range ids {
+		tcRows, err := s.db.Query(`SELECT tool_call_id, name, arguments FROM tool_calls WHERE message_id = ?`, id)
+		if err != nil {
+			return nil, err
+		}
+		for tcRows.Next() {
+			var tc ToolCall
+			if err := tcRows.Scan(&tc.ID, &tc.Name, &tc.Arguments); err != nil {
+				tcRows.Close()
+				return nil, err
+			}
+			msgs[i].ToolCalls = append(msgs[i].ToolCalls, tc)
+		}
+		tcRows.Close()
+		if err := tcRows.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	return msgs, nil
+}
+
+// listConversations returns every conversation, most recently updated
+// first, for `agent list`.
+func (s *ConversationStore) listConversations() ([]ConversationSummary, error) {
+	rows, err := s.db.Query(`SELECT id, parent_id, agent_name, title, prompt_tokens, completion_tokens, updated_at FROM conversations ORDER BY updated_at DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ConversationSummary
+	for rows.Next() {
+		var c ConversationSummary
+		if err := rows.Scan(&c.ID, &c.ParentID, &c.AgentName, &c.Title, &c.PromptTokens, &c.CompletionTokens, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// generateTitle asks the provider for a short title summarizing msgs,
+// considering only user and assistant turns (mirroring lmcli's
+// approach of excluding tool noise from the title prompt).
+func generateTitle(ctx context.Context, provider Provider, msgs []Message) (string, error) {
+	var transcript strings.Builder
+	for _, m := range msgs {
+		if m.Role != "user" && m.Role != "assistant" {
+			continue
+		}
+		if m.Content == "" {
+			continue
+		}
+		fmt.Fprintf(&transcript, "%s: %s\n", m.Role, m.Content)
+	}
+	if transcript.Len() == 0 {
+		return "", nil
+	}
+
+	titleMsgs := []Message{
+		{Role: "system", Content: "Summarize the following conversation in a short title of no more than 8 words. Respond with only the title, no punctuation or quotes."},
+		{Role: "user", Content: transcript.String()},
+	}
+
+	resp, err := provider.Chat(ctx, titleMsgs, nil)
+	if err != nil {
+		return "", err
+	}
+	return strings.Trim(strings.TrimSpace(resp.Message.Content), "\"'"), nil
+}
+
+// summarizeMessages asks the provider for a short prose summary of
+// msgs, used by AgentState.compactConversation to replace the oldest
+// half of a conversation with a single system note when a
+// ContextLengthError hits.
+func summarizeMessages(ctx context.Context, provider Provider, msgs []Message) (string, error) {
+	var transcript strings.Builder
+	for _, m := range msgs {
+		switch m.Role {
+		case "user", "assistant":
+			if m.Content != "" {
+				fmt.Fprintf(&transcript, "%s: %s\n", m.Role, m.Content)
+			}
+			for _, tc := range m.ToolCalls {
+				fmt.Fprintf(&transcript, "%s called %s(%s)\n", m.Role, tc.Name, tc.Arguments)
+			}
+		case "tool":
+			fmt.Fprintf(&transcript, "tool result: %s\n", m.Content)
+		}
+	}
+
+	summaryMsgs := []Message{
+		{Role: "system", Content: "Summarize the following portion of an agent conversation in a short paragraph, preserving any facts, decisions, or file paths a later turn might need."},
+		{Role: "user", Content: transcript.String()},
+	}
+
+	resp, err := provider.Chat(ctx, summaryMsgs, nil)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(resp.Message.Content), nil
+}
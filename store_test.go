@@ -0,0 +1,121 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func openTestStore(t *testing.T) *ConversationStore {
+	t.Helper()
+
+	os.MkdirAll("test_data", 0755)
+	t.Cleanup(func() { os.RemoveAll("test_data") })
+
+	path := filepath.Join("test_data", "store_"+t.Name()+".db")
+	os.Remove(path)
+
+	s, err := openConversationStore(path)
+	if err != nil {
+		t.Fatalf("openConversationStore() error = %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestConversationStoreAppendLoadRoundTrip(t *testing.T) {
+	s := openTestStore(t)
+
+	convID, err := s.startConversation("coder", nil)
+	if err != nil {
+		t.Fatalf("startConversation() error = %v", err)
+	}
+
+	msgs := []Message{
+		{Role: "user", Content: "list the files"},
+		{
+			Role: "assistant",
+			ToolCalls: []ToolCall{
+				{ID: "call_1", Name: "bash", Arguments: `{"command":"ls"}`},
+			},
+		},
+		{Role: "tool", Content: "a.go\nb.go", ToolCallID: "call_1"},
+		{Role: "assistant", Content: "The files are a.go and b.go."},
+	}
+
+	if err := s.appendMessages(convID, msgs, 0, Usage{PromptTokens: 10, CompletionTokens: 5}); err != nil {
+		t.Fatalf("appendMessages() error = %v", err)
+	}
+
+	got, err := s.loadMessages(convID)
+	if err != nil {
+		t.Fatalf("loadMessages() error = %v", err)
+	}
+
+	if len(got) != len(msgs) {
+		t.Fatalf("loadMessages() returned %d messages, want %d", len(got), len(msgs))
+	}
+	for i, want := range msgs {
+		if got[i].Role != want.Role || got[i].Content != want.Content || got[i].ToolCallID != want.ToolCallID {
+			t.Errorf("message %d = %+v, want %+v", i, got[i], want)
+		}
+		if len(got[i].ToolCalls) != len(want.ToolCalls) {
+			t.Errorf("message %d has %d tool calls, want %d", i, len(got[i].ToolCalls), len(want.ToolCalls))
+			continue
+		}
+		for j, tc := range want.ToolCalls {
+			if got[i].ToolCalls[j] != tc {
+				t.Errorf("message %d tool call %d = %+v, want %+v", i, j, got[i].ToolCalls[j], tc)
+			}
+		}
+	}
+
+	convs, err := s.listConversations()
+	if err != nil {
+		t.Fatalf("listConversations() error = %v", err)
+	}
+	if len(convs) != 1 {
+		t.Fatalf("listConversations() returned %d rows, want 1", len(convs))
+	}
+	if convs[0].PromptTokens != 10 || convs[0].CompletionTokens != 5 {
+		t.Errorf("conversation usage = %+v, want prompt=10 completion=5", convs[0])
+	}
+}
+
+func TestConversationStoreAppendMessagesIsIncremental(t *testing.T) {
+	s := openTestStore(t)
+
+	convID, err := s.startConversation("shell", nil)
+	if err != nil {
+		t.Fatalf("startConversation() error = %v", err)
+	}
+
+	first := []Message{{Role: "user", Content: "hello"}}
+	if err := s.appendMessages(convID, first, 0, Usage{PromptTokens: 1, CompletionTokens: 1}); err != nil {
+		t.Fatalf("appendMessages() first call error = %v", err)
+	}
+
+	second := append(first, Message{Role: "assistant", Content: "hi there"})
+	if err := s.appendMessages(convID, second, len(first), Usage{PromptTokens: 2, CompletionTokens: 2}); err != nil {
+		t.Fatalf("appendMessages() second call error = %v", err)
+	}
+
+	got, err := s.loadMessages(convID)
+	if err != nil {
+		t.Fatalf("loadMessages() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("loadMessages() returned %d messages, want 2 (no duplicates from the already-persisted prefix)", len(got))
+	}
+	if got[0].Content != "hello" || got[1].Content != "hi there" {
+		t.Errorf("loadMessages() = %+v, want [hello, hi there] in order", got)
+	}
+
+	convs, err := s.listConversations()
+	if err != nil {
+		t.Fatalf("listConversations() error = %v", err)
+	}
+	if convs[0].PromptTokens != 3 || convs[0].CompletionTokens != 3 {
+		t.Errorf("conversation usage = %+v, want cumulative prompt=3 completion=3", convs[0])
+	}
+}
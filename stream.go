@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// streamModel is a minimal bubbletea model that renders assistant
+// content as it streams in, giving the huh-driven UI a live "typing"
+// bubble instead of a blocking wait (as mods does for its own replies).
+type streamModel struct {
+	content string
+	done    bool
+	err     error
+}
+
+type streamChunkMsg string
+type streamDoneMsg struct{ err error }
+
+func newStreamModel() streamModel {
+	return streamModel{}
+}
+
+func (m streamModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m streamModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case streamChunkMsg:
+		m.content += string(msg)
+		return m, nil
+	case streamDoneMsg:
+		m.done = true
+		m.err = msg.err
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m streamModel) View() string {
+	if m.err != nil {
+		return fmt.Sprintf("Agent: %s\n(stream error: %v)\n", m.content, m.err)
+	}
+	cursor := " ▋"
+	if m.done {
+		cursor = ""
+	}
+	return fmt.Sprintf("Agent: %s%s\n", m.content, cursor)
+}
+
+// runStreaming drives a bubbletea "typing" view while provider streams
+// its response, returning the fully assembled Response exactly as a
+// blocking Chat call would have.
+func runStreaming(ctx context.Context, provider Provider, msgs []Message, tools []ToolSpec) (Response, error) {
+	program := tea.NewProgram(newStreamModel())
+	done := make(chan struct{})
+	go func() {
+		program.Run()
+		close(done)
+	}()
+
+	resp, err := provider.ChatStream(ctx, msgs, tools, func(delta StreamDelta) {
+		program.Send(streamChunkMsg(delta))
+	})
+
+	program.Send(streamDoneMsg{err: err})
+	<-done
+
+	return resp, err
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TodoArgs are the arguments for the todo tool. Content's
+// interpretation depends on Action; see executeTodo.
+type TodoArgs struct {
+	Action  string `json:"action"`
+	Path    string `json:"path"`
+	Content string `json:"content"`
+}
+
+// executeTodo manages a markdown checklist file at filePath. The
+// interpretation of content depends on action: the task text for "add"
+// and "complete", "old item -> new item" for "update", the full file
+// body for "write", and unused for "read".
+func executeTodo(action, filePath, content string, timeout time.Duration) Result {
+	switch action {
+	case "read":
+		data, err := activeFS.ReadFile(filePath)
+		if err != nil {
+			return Result{Stderr: fmt.Sprintf("Todo file does not exist: %s", filePath), ExitCode: 1}
+		}
+		return Result{Stdout: string(data), ExitCode: 0}
+
+	case "write":
+		before, _ := activeFS.ReadFile(filePath)
+		if err := activeFS.WriteFile(filePath, []byte(content), 0644); err != nil {
+			return Result{Stderr: err.Error(), ExitCode: 1}
+		}
+		recordOp("todo", filePath, string(before), content, map[string]string{"action": "write"})
+		return Result{Stdout: "Todo file written", ExitCode: 0}
+
+	case "add":
+		return addTodoItem(filePath, content)
+
+	case "complete":
+		return transformTodoItem(filePath, content, func(trimmed string) (string, bool) {
+			if trimmed != fmt.Sprintf("- [ ] %s", content) {
+				return "", false
+			}
+			return fmt.Sprintf("- [x] %s", content), true
+		})
+
+	case "update":
+		parts := strings.SplitN(content, " -> ", 2)
+		if len(parts) != 2 {
+			return Result{Stderr: "Update format must be 'old item -> new item'", ExitCode: 1}
+		}
+		oldItem, newItem := parts[0], parts[1]
+		return transformTodoItem(filePath, oldItem, func(trimmed string) (string, bool) {
+			switch trimmed {
+			case fmt.Sprintf("- [ ] %s", oldItem):
+				return fmt.Sprintf("- [ ] %s", newItem), true
+			case fmt.Sprintf("- [x] %s", oldItem):
+				return fmt.Sprintf("- [x] %s", newItem), true
+			default:
+				return "", false
+			}
+		})
+
+	default:
+		return Result{Stderr: fmt.Sprintf("Unknown todo action: %s", action), ExitCode: 1}
+	}
+}
+
+func addTodoItem(filePath, task string) Result {
+	var before string
+	body := "# Todo List\n\n"
+	if existing, err := activeFS.ReadFile(filePath); err == nil {
+		before = string(existing)
+		body = before
+	}
+	if body != "" && !strings.HasSuffix(body, "\n") {
+		body += "\n"
+	}
+	body += fmt.Sprintf("- [ ] %s\n", task)
+
+	if err := activeFS.WriteFile(filePath, []byte(body), 0644); err != nil {
+		return Result{Stderr: err.Error(), ExitCode: 1}
+	}
+	recordOp("todo", filePath, before, body, map[string]string{"action": "add", "task": task})
+	return Result{Stdout: "Task added", ExitCode: 0}
+}
+
+func transformTodoItem(filePath, itemDescription string, transform func(trimmed string) (string, bool)) Result {
+	data, err := activeFS.ReadFile(filePath)
+	if err != nil {
+		return Result{Stderr: fmt.Sprintf("Todo file does not exist: %s", filePath), ExitCode: 1}
+	}
+
+	lines := strings.Split(string(data), "\n")
+	found := false
+	for i, line := range lines {
+		newLine, matched := transform(strings.TrimSpace(line))
+		if matched {
+			lines[i] = newLine
+			found = true
+			break
+		}
+	}
+
+	if !found {
+		return Result{Stderr: fmt.Sprintf("Item not found: %s", itemDescription), ExitCode: 1}
+	}
+
+	after := strings.Join(lines, "\n")
+	if err := activeFS.WriteFile(filePath, []byte(after), 0644); err != nil {
+		return Result{Stderr: err.Error(), ExitCode: 1}
+	}
+	recordOp("todo", filePath, string(data), after, map[string]string{"item": itemDescription})
+	return Result{Stdout: "Todo item updated", ExitCode: 0}
+}
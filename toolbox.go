@@ -0,0 +1,567 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ToolHandler executes one tool call's arguments against agent state,
+// returning the tool's result and whether the agent loop should stop
+// after this call (as "finish" does).
+type ToolHandler func(a *AgentState, arguments string) (result string, done bool)
+
+// toolboxEntry pairs a tool's model-facing spec with its dispatch
+// handler.
+type toolboxEntry struct {
+	Spec    ToolSpec
+	Handler ToolHandler
+}
+
+// defaultToolTimeout bounds the shell-backed tools below that don't
+// take an explicit timeout argument from the model.
+const defaultToolTimeout = 10 * time.Second
+
+// formatResult renders a Result the way handleBashCommand renders a
+// failed bash invocation: a non-zero exit surfaces stderr alongside
+// whatever stdout was captured, success returns stdout (plus any
+// stderr a tool still wrote on the happy path, e.g. warnings).
+func formatResult(r Result) string {
+	if r.ExitCode != 0 {
+		return fmt.Sprintf("Command failed (exit %d): %s\nOutput: %s", r.ExitCode, r.Stderr, r.Stdout)
+	}
+	if r.Stderr != "" {
+		return fmt.Sprintf("%s\n%s", r.Stdout, r.Stderr)
+	}
+	return r.Stdout
+}
+
+// globalToolbox registers every tool this binary knows how to execute.
+// An Agent exposes a subset of it by name; tools an Agent lists that
+// aren't registered here aren't offered to the model, so new tools
+// must be added here to be reachable.
+var globalToolbox = map[string]toolboxEntry{
+	"bash": {
+		Spec: ToolSpec{
+			Name:        "bash",
+			Description: "Execute a shell command using bash",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"command": map[string]interface{}{
+						"type":        "string",
+						"description": "The shell command to execute",
+					},
+				},
+				"required": []string{"command"},
+			},
+		},
+		Handler: bashToolHandler,
+	},
+	"finish": {
+		Spec: ToolSpec{
+			Name:        "finish",
+			Description: "Finish the current task and exit the agent loop",
+			Parameters: map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{},
+			},
+		},
+		Handler: finishToolHandler,
+	},
+	"read_file": {
+		Spec: ToolSpec{
+			Name:        "read_file",
+			Description: "Read the full contents of a file",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Path to the file to read",
+					},
+				},
+				"required": []string{"path"},
+			},
+		},
+		Handler: readFileToolHandler,
+	},
+	"write_file": {
+		Spec: ToolSpec{
+			Name:        "write_file",
+			Description: "Write content to a file, creating it (and any parent directories) if it doesn't exist, overwriting it otherwise. Shows a diff and asks for confirmation before applying",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Path to the file to write",
+					},
+					"content": map[string]interface{}{
+						"type":        "string",
+						"description": "The full content to write to the file",
+					},
+				},
+				"required": []string{"path", "content"},
+			},
+		},
+		Handler: writeFileToolHandler,
+	},
+	"modify_file": {
+		Spec: ToolSpec{
+			Name:        "modify_file",
+			Description: "Replace an exact, unique occurrence of old_string with new_string in path. Fails if old_string appears zero or multiple times, requiring more surrounding context. Shows a diff and asks for confirmation before applying",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path": map[string]interface{}{
+						"type":        "string",
+						"description": "Path to the file to modify",
+					},
+					"old_string": map[string]interface{}{
+						"type":        "string",
+						"description": "The exact text to replace; must match exactly once in the file",
+					},
+					"new_string": map[string]interface{}{
+						"type":        "string",
+						"description": "The text to replace it with",
+					},
+				},
+				"required": []string{"path", "old_string", "new_string"},
+			},
+		},
+		Handler: modifyFileToolHandler,
+	},
+	"expect": {
+		Spec: ToolSpec{
+			Name:        "expect",
+			Description: "Spawn command under a PTY and drive it through a scripted sequence of expect/send steps, for REPLs, ssh, sudo prompts, psql, gh auth login, etc. Each step either waits for a pattern (literal or regex, optionally capturing named submatches) or sends text to stdin",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"command": map[string]interface{}{
+						"type":        "string",
+						"description": "The command to spawn under a PTY",
+					},
+					"steps": map[string]interface{}{
+						"type":        "array",
+						"description": "Ordered expect/send steps",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"pattern":         map[string]interface{}{"type": "string", "description": "Substring or regex to wait for; omit for a send-only step"},
+								"regex":           map[string]interface{}{"type": "boolean", "description": "Interpret pattern as a regex instead of a literal substring"},
+								"send":            map[string]interface{}{"type": "string", "description": "Text to write to stdin (a trailing newline is added); omit for a wait-only step"},
+								"timeout_seconds": map[string]interface{}{"type": "integer", "description": "Per-step timeout; defaults to the overall timeout"},
+								"captures":        map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}, "description": "Named regex submatches to capture into the result"},
+							},
+						},
+					},
+					"timeout_seconds": map[string]interface{}{
+						"type":        "integer",
+						"description": "Overall timeout in seconds; defaults to 10",
+					},
+				},
+				"required": []string{"command", "steps"},
+			},
+		},
+		Handler: expectToolHandler,
+	},
+	"sed": {
+		Spec: ToolSpec{
+			Name:        "sed",
+			Description: "Replace search with replace (sed's s///g) in path. Requires a dry_run call with identical path/search/replace before dry_run=false is accepted, so the model can preview the change before applying it",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path":    map[string]interface{}{"type": "string", "description": "Path to the file to edit"},
+					"search":  map[string]interface{}{"type": "string", "description": "sed search pattern"},
+					"replace": map[string]interface{}{"type": "string", "description": "sed replacement pattern"},
+					"dry_run": map[string]interface{}{"type": "boolean", "description": "Preview the change without writing it; must be true before dry_run=false is accepted"},
+				},
+				"required": []string{"path", "search", "replace"},
+			},
+		},
+		Handler: sedToolHandler,
+	},
+	"comby": {
+		Spec: ToolSpec{
+			Name:        "comby",
+			Description: "Run the comby structural search/rewrite tool. Matches match_template against target; if rewrite_template is also set and match_only is false, rewrites matches (in place when in_place is set, as a diff when diff is set). format=\"json\" returns structured per-match records instead of comby's text output. Setting shards>1 splits a directory target across that many shards (use shard to pick which)",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"match_template":   map[string]interface{}{"type": "string", "description": "comby match template, e.g. \"fmt.Println(:[args])\""},
+					"rewrite_template": map[string]interface{}{"type": "string", "description": "comby rewrite template; omit for a match-only run"},
+					"target":           map[string]interface{}{"type": "string", "description": "File or directory to search"},
+					"match_only":       map[string]interface{}{"type": "boolean", "description": "Report matches without rewriting even if rewrite_template is set"},
+					"in_place":         map[string]interface{}{"type": "boolean", "description": "Rewrite target in place"},
+					"diff":             map[string]interface{}{"type": "boolean", "description": "Show a diff of the rewrite instead of writing it"},
+					"ext":              map[string]interface{}{"type": "string", "description": "File extension matcher, e.g. \".go\""},
+					"lang":             map[string]interface{}{"type": "string", "description": "comby named matcher, e.g. \"go\"; mutually exclusive with ext"},
+					"format":           map[string]interface{}{"type": "string", "description": "\"text\" (default) or \"json\" for structured matches"},
+					"shards":           map[string]interface{}{"type": "integer", "description": "Split a directory target across this many shards"},
+					"shard":            map[string]interface{}{"type": "integer", "description": "Which shard to run, 0-indexed; requires shards"},
+					"parallelism":      map[string]interface{}{"type": "integer", "description": "Worker count per shard; defaults to the number of CPUs"},
+				},
+				"required": []string{"match_template", "target"},
+			},
+		},
+		Handler: combyToolHandler,
+	},
+	"todo": {
+		Spec: ToolSpec{
+			Name:        "todo",
+			Description: "Manage a markdown checklist file. action is one of read, write, add, complete, update: write replaces the whole file with content; add appends content as a new \"- [ ]\" item; complete marks the item matching content done; update expects content as \"old item -> new item\"",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"action":  map[string]interface{}{"type": "string", "description": "One of read, write, add, complete, update"},
+					"path":    map[string]interface{}{"type": "string", "description": "Path to the todo markdown file"},
+					"content": map[string]interface{}{"type": "string", "description": "Action-specific payload; see the action descriptions"},
+				},
+				"required": []string{"action", "path"},
+			},
+		},
+		Handler: todoToolHandler,
+	},
+	"ops_list": {
+		Spec: ToolSpec{
+			Name:        "ops_list",
+			Description: "List recorded sed/comby/todo mutations from the on-disk operation ledger, optionally filtered by path, tool name, and/or time window",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"path":  map[string]interface{}{"type": "string", "description": "Only list operations targeting this path"},
+					"tool":  map[string]interface{}{"type": "string", "description": "Only list operations from this tool (sed, comby, or todo)"},
+					"since": map[string]interface{}{"type": "string", "description": "RFC3339 timestamp; only list operations at or after this time"},
+					"until": map[string]interface{}{"type": "string", "description": "RFC3339 timestamp; only list operations at or before this time"},
+				},
+				"required": []string{},
+			},
+		},
+		Handler: opsListToolHandler,
+	},
+	"ops_revert": {
+		Spec: ToolSpec{
+			Name:        "ops_revert",
+			Description: "Revert a ledger operation by id, restoring its target file to its pre-operation content. Refuses if the file no longer matches the recorded post-operation hash",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"op_id": map[string]interface{}{"type": "string", "description": "The operation id reported by ops_list"},
+				},
+				"required": []string{"op_id"},
+			},
+		},
+		Handler: opsRevertToolHandler,
+	},
+	"shell_batch": {
+		Spec: ToolSpec{
+			Name:        "shell_batch",
+			Description: "Run several shell commands concurrently with a bounded worker pool, each output line-prefixed by its label, and return a pass/fail/timeout summary",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"commands": map[string]interface{}{
+						"type":        "array",
+						"description": "Commands to run",
+						"items": map[string]interface{}{
+							"type": "object",
+							"properties": map[string]interface{}{
+								"label":   map[string]interface{}{"type": "string", "description": "Prefixes this command's output lines and shards on when sharding"},
+								"command": map[string]interface{}{"type": "string", "description": "The shell command to execute"},
+							},
+							"required": []string{"label", "command"},
+						},
+					},
+					"max_parallel": map[string]interface{}{"type": "integer", "description": "Maximum concurrent commands; defaults to the number of CPUs"},
+					"shard":        map[string]interface{}{"type": "integer", "description": "Which shard to run, 0-indexed; requires shards"},
+					"shards":       map[string]interface{}{"type": "integer", "description": "Split commands across this many shards by hashing their label"},
+					"fail_fast":    map[string]interface{}{"type": "boolean", "description": "Stop starting new commands once one has failed or timed out"},
+				},
+				"required": []string{"commands"},
+			},
+		},
+		Handler: shellBatchToolHandler,
+	},
+	"gofmt": {
+		Spec: ToolSpec{
+			Name:        "gofmt",
+			Description: "Format Go source under target (a file or directory), mirroring gofmt's -l/-d/-w flags. in_process formats via go/format instead of shelling out to the gofmt binary. format=\"json\" returns structured per-file records instead of gofmt's text output. Setting shards>1 splits a directory target across that many shards (use shard to pick which)",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"target":      map[string]interface{}{"type": "string", "description": "File or directory to format; defaults to \".\""},
+					"list":        map[string]interface{}{"type": "boolean", "description": "List files that need formatting"},
+					"diff":        map[string]interface{}{"type": "boolean", "description": "Show a diff instead of writing it"},
+					"write":       map[string]interface{}{"type": "boolean", "description": "Write the formatted result back to disk"},
+					"in_process":  map[string]interface{}{"type": "boolean", "description": "Format via go/format in-process instead of the gofmt binary"},
+					"format":      map[string]interface{}{"type": "string", "description": "\"text\" (default) or \"json\" for structured per-file records"},
+					"shards":      map[string]interface{}{"type": "integer", "description": "Split a directory target across this many shards"},
+					"shard":       map[string]interface{}{"type": "integer", "description": "Which shard to run, 0-indexed; requires shards"},
+					"parallelism": map[string]interface{}{"type": "integer", "description": "Worker count per shard; defaults to the number of CPUs"},
+				},
+				"required": []string{"target"},
+			},
+		},
+		Handler: gofmtToolHandler,
+	},
+	"goimports": {
+		Spec: ToolSpec{
+			Name:        "goimports",
+			Description: "Format Go source under target and fix its imports in-process (add missing, remove unused), mirroring goimports' -l/-d/-w flags. local_prefix groups matching import paths into their own block, like goimports' -local flag",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"target":       map[string]interface{}{"type": "string", "description": "File or directory to fix; defaults to \".\""},
+					"list":         map[string]interface{}{"type": "boolean", "description": "List files that need fixing"},
+					"diff":         map[string]interface{}{"type": "boolean", "description": "Show a diff instead of writing it"},
+					"write":        map[string]interface{}{"type": "boolean", "description": "Write the fixed result back to disk"},
+					"local_prefix": map[string]interface{}{"type": "string", "description": "Import path prefix to group into its own block"},
+				},
+				"required": []string{"target"},
+			},
+		},
+		Handler: goimportsToolHandler,
+	},
+	"gorewrite": {
+		Spec: ToolSpec{
+			Name:        "gorewrite",
+			Description: "Structural search/rewrite of Go call expressions via go/ast, avoiding a dependency on the comby binary. Same surface as comby's match_template/rewrite_template for patterns like \"fmt.Println(:[args]) -> log.Println(:[args])\"; falls back to comby for patterns the native engine doesn't support yet (e.g. regex holes)",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"match_template":   map[string]interface{}{"type": "string", "description": "Call pattern to match, e.g. \"fmt.Println(:[args])\""},
+					"rewrite_template": map[string]interface{}{"type": "string", "description": "Call pattern to rewrite to; omit for a match-only run"},
+					"target":           map[string]interface{}{"type": "string", "description": "File or directory to search"},
+					"match_only":       map[string]interface{}{"type": "boolean", "description": "Report matches without rewriting even if rewrite_template is set"},
+					"diff":             map[string]interface{}{"type": "boolean", "description": "Show a diff of the rewrite instead of writing it"},
+					"in_place":         map[string]interface{}{"type": "boolean", "description": "Rewrite target in place"},
+					"ext":              map[string]interface{}{"type": "string", "description": "File extension filter, e.g. \".go\""},
+				},
+				"required": []string{"match_template", "target"},
+			},
+		},
+		Handler: gorewriteToolHandler,
+	},
+}
+
+func bashToolHandler(a *AgentState, arguments string) (string, bool) {
+	var args BashArgs
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return fmt.Sprintf("Error parsing bash arguments: %v", err), false
+	}
+	return a.handleBashCommand(args.Command), false
+}
+
+func finishToolHandler(a *AgentState, arguments string) (string, bool) {
+	fmt.Println("Agent finished the task.")
+	return "", true
+}
+
+func readFileToolHandler(a *AgentState, arguments string) (string, bool) {
+	var args ReadFileArgs
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return fmt.Sprintf("Error parsing read_file arguments: %v", err), false
+	}
+	return a.handleReadFile(args.Path), false
+}
+
+func writeFileToolHandler(a *AgentState, arguments string) (string, bool) {
+	var args WriteFileArgs
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return fmt.Sprintf("Error parsing write_file arguments: %v", err), false
+	}
+	return a.handleWriteFile(args.Path, args.Content), false
+}
+
+func modifyFileToolHandler(a *AgentState, arguments string) (string, bool) {
+	var args ModifyFileArgs
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return fmt.Sprintf("Error parsing modify_file arguments: %v", err), false
+	}
+	return a.handleModifyFile(args.Path, args.OldString, args.NewString), false
+}
+
+func expectToolHandler(a *AgentState, arguments string) (string, bool) {
+	var args ExpectArgs
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return fmt.Sprintf("Error parsing expect arguments: %v", err), false
+	}
+
+	overall := defaultToolTimeout
+	if args.TimeoutSeconds > 0 {
+		overall = time.Duration(args.TimeoutSeconds) * time.Second
+	}
+
+	steps := make([]ExpectStep, len(args.Steps))
+	for i, s := range args.Steps {
+		mode := MatchLiteral
+		if s.Regex {
+			mode = MatchRegex
+		}
+		stepTimeout := overall
+		if s.TimeoutSeconds > 0 {
+			stepTimeout = time.Duration(s.TimeoutSeconds) * time.Second
+		}
+		steps[i] = ExpectStep{
+			Pattern:  s.Pattern,
+			Mode:     mode,
+			Send:     s.Send,
+			Timeout:  stepTimeout,
+			Captures: s.Captures,
+		}
+	}
+
+	result := executeExpect(args.Command, steps, overall)
+	if result.Err != nil {
+		return fmt.Sprintf("expect failed (exit %d): %v\nTranscript: %s", result.ExitCode, result.Err, result.Transcript), false
+	}
+	return fmt.Sprintf("Transcript: %s\nCaptures: %v", result.Transcript, result.Captures), false
+}
+
+func sedToolHandler(a *AgentState, arguments string) (string, bool) {
+	var args SedArgs
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return fmt.Sprintf("Error parsing sed arguments: %v", err), false
+	}
+	return formatResult(executeSed(args.Path, args.Search, args.Replace, args.DryRun, defaultToolTimeout)), false
+}
+
+func combyToolHandler(a *AgentState, arguments string) (string, bool) {
+	var args CombyArgs
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return fmt.Sprintf("Error parsing comby arguments: %v", err), false
+	}
+
+	if args.Shards > 1 {
+		opts := RunOptions{Parallelism: args.Parallelism, Shard: args.Shard, Shards: args.Shards}
+		aggregated := executeCombyTree(args.MatchTemplate, args.RewriteTemplate, args.Target, args.Ext, args.Lang, args.Diff, defaultToolTimeout, opts)
+		return formatResult(aggregated.Combined), false
+	}
+
+	if args.Format == "json" {
+		return formatResult(executeCombyJSON(args.MatchTemplate, args.RewriteTemplate, args.Target, args.Ext, args.Lang, defaultToolTimeout)), false
+	}
+	return formatResult(executeComby(args.MatchTemplate, args.RewriteTemplate, args.Target, args.MatchOnly, args.InPlace, args.Diff, args.Ext, args.Lang, defaultToolTimeout)), false
+}
+
+func todoToolHandler(a *AgentState, arguments string) (string, bool) {
+	var args TodoArgs
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return fmt.Sprintf("Error parsing todo arguments: %v", err), false
+	}
+	return formatResult(executeTodo(args.Action, args.Path, args.Content, defaultToolTimeout)), false
+}
+
+func opsListToolHandler(a *AgentState, arguments string) (string, bool) {
+	var args OpsListArgs
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return fmt.Sprintf("Error parsing ops_list arguments: %v", err), false
+	}
+
+	var since, until time.Time
+	if args.Since != "" {
+		since, _ = time.Parse(time.RFC3339, args.Since)
+	}
+	if args.Until != "" {
+		until, _ = time.Parse(time.RFC3339, args.Until)
+	}
+	return formatResult(executeOpsList(args.Path, args.Tool, since, until)), false
+}
+
+func opsRevertToolHandler(a *AgentState, arguments string) (string, bool) {
+	var args OpsRevertArgs
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return fmt.Sprintf("Error parsing ops_revert arguments: %v", err), false
+	}
+	return formatResult(executeOpsRevert(args.OpID)), false
+}
+
+func shellBatchToolHandler(a *AgentState, arguments string) (string, bool) {
+	var args ShellBatchArgs
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return fmt.Sprintf("Error parsing shell_batch arguments: %v", err), false
+	}
+
+	commands := make([]BatchCommand, len(args.Commands))
+	for i, c := range args.Commands {
+		commands[i] = BatchCommand{Label: c.Label, Command: c.Command, Timeout: defaultToolTimeout}
+	}
+
+	run := executeShellBatch(commands, BatchOpts{
+		MaxParallel: args.MaxParallel,
+		Shard:       args.Shard,
+		Shards:      args.Shards,
+		FailFast:    args.FailFast,
+	})
+
+	var out string
+	for r := range run.Results {
+		status := "ok"
+		if r.TimedOut {
+			status = "timed out"
+		} else if r.ExitCode != 0 {
+			status = fmt.Sprintf("failed (exit %d)", r.ExitCode)
+		}
+		out += fmt.Sprintf("[%s] %s\n%s%s", r.Label, status, r.Stdout, r.Stderr)
+	}
+
+	summary := run.Summary()
+	out += fmt.Sprintf("\nSummary: %d passed, %d failed, %d timed out, %s total\n", summary.Passed, summary.Failed, summary.TimedOut, summary.Total)
+	return out, false
+}
+
+func gofmtToolHandler(a *AgentState, arguments string) (string, bool) {
+	var args GofmtArgs
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return fmt.Sprintf("Error parsing gofmt arguments: %v", err), false
+	}
+
+	if args.Shards > 1 {
+		opts := RunOptions{Parallelism: args.Parallelism, Shard: args.Shard, Shards: args.Shards}
+		aggregated := executeGofmtTree(args.Target, args.List, args.Diff, args.Write, defaultToolTimeout, opts)
+		return formatResult(aggregated.Combined), false
+	}
+
+	if args.Format == "json" {
+		return formatResult(executeGofmtJSON(args.Target, defaultToolTimeout)), false
+	}
+
+	mode := GofmtShell
+	if args.InProcess {
+		mode = GofmtInProcess
+	}
+	return formatResult(executeGofmtMode(args.Target, args.List, args.Diff, args.Write, mode, defaultToolTimeout)), false
+}
+
+func goimportsToolHandler(a *AgentState, arguments string) (string, bool) {
+	var args GoimportsArgs
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return fmt.Sprintf("Error parsing goimports arguments: %v", err), false
+	}
+	return formatResult(executeGoimports(args.Target, args.List, args.Diff, args.Write, args.LocalPrefix, defaultToolTimeout)), false
+}
+
+func gorewriteToolHandler(a *AgentState, arguments string) (string, bool) {
+	var args GorewriteArgs
+	if err := json.Unmarshal([]byte(arguments), &args); err != nil {
+		return fmt.Sprintf("Error parsing gorewrite arguments: %v", err), false
+	}
+	return formatResult(executeGorewrite(args.MatchTemplate, args.RewriteTemplate, args.Target, args.MatchOnly, args.Diff, args.InPlace, args.Ext, defaultToolTimeout)), false
+}
+
+// toolSpecsFor resolves an Agent's tool names against globalToolbox,
+// skipping any name that isn't registered yet.
+func toolSpecsFor(agent Agent) []ToolSpec {
+	var specs []ToolSpec
+	for _, name := range agent.Tools {
+		entry, ok := globalToolbox[name]
+		if !ok {
+			continue
+		}
+		specs = append(specs, entry.Spec)
+	}
+	return specs
+}
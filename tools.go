@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"time"
+)
+
+// Result is the common shape returned by every shell-backed tool: the
+// captured stdout/stderr and the process exit code.
+type Result struct {
+	Stdout   string
+	Stderr   string
+	ExitCode int
+}
+
+// runCommand runs name with args under timeout, capturing stdout/stderr
+// separately and normalizing the exit code for callers.
+func runCommand(timeout time.Duration, name string, args ...string) Result {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+
+	exitCode := 0
+	if err != nil {
+		switch {
+		case ctx.Err() == context.DeadlineExceeded:
+			exitCode = 1
+			stderr.WriteString("command timed out")
+		default:
+			if exitErr, ok := err.(*exec.ExitError); ok {
+				exitCode = exitErr.ExitCode()
+			} else {
+				exitCode = 1
+				stderr.WriteString(err.Error())
+			}
+		}
+	}
+
+	return Result{
+		Stdout:   stdout.String(),
+		Stderr:   stderr.String(),
+		ExitCode: exitCode,
+	}
+}
+
+func executeShellCommand(command string, timeout time.Duration) Result {
+	return runCommand(timeout, "bash", "-c", command)
+}
+
+func executeGoDoc(packageOrSymbol string, timeout time.Duration) Result {
+	return runCommand(timeout, "go", "doc", packageOrSymbol)
+}
+
+func executeRipgrep(pattern, path string, ignoreCase, lineNumbers, filesWithMatches bool, timeout time.Duration) Result {
+	var args []string
+	if ignoreCase {
+		args = append(args, "-i")
+	}
+	if lineNumbers {
+		args = append(args, "-n")
+	}
+	if filesWithMatches {
+		args = append(args, "-l")
+	}
+	args = append(args, pattern, path)
+
+	return runCommand(timeout, "rg", args...)
+}